@@ -0,0 +1,62 @@
+package prelviz
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildOption is the build constraints which select go files by the same rules as go build.
+// ex) Tags: []string{"integration"}, GOOS: "linux", GOARCH: "amd64"
+// Empty GOOS and GOARCH are the ones of the running environment, and a nil option selects the go files of the running environment.
+type BuildOption struct {
+	Tags   []string
+	GOOS   string
+	GOARCH string
+}
+
+// context returns the build context which matches files by the build constraints.
+func (o *BuildOption) context() *build.Context {
+	ctx := build.Default
+	if o == nil {
+		return &ctx
+	}
+	if o.GOOS != "" && o.GOOS != ctx.GOOS || o.GOARCH != "" && o.GOARCH != ctx.GOARCH {
+		// go build disables cgo by default when cross compiling.
+		ctx.CgoEnabled = false
+	}
+	if o.GOOS != "" {
+		ctx.GOOS = o.GOOS
+	}
+	if o.GOARCH != "" {
+		ctx.GOARCH = o.GOARCH
+	}
+	ctx.BuildTags = o.Tags
+	return &ctx
+}
+
+// matchFile reports whether the go file is built under the build constraints.
+// Like go build, the files which need the "ignore" tag, such as programs run by go generate, never match.
+func (o *BuildOption) matchFile(filePath string) (bool, error) {
+	return o.context().MatchFile(filepath.Dir(filePath), filepath.Base(filePath))
+}
+
+// buildFlags returns the flags and the environment variables of the go command for the build constraints.
+func (o *BuildOption) buildFlags() ([]string, []string) {
+	if o == nil {
+		return nil, nil
+	}
+	flags := make([]string, 0)
+	if len(o.Tags) > 0 {
+		flags = append(flags, "-tags="+strings.Join(o.Tags, ","))
+	}
+	env := os.Environ()
+	if o.GOOS != "" {
+		env = append(env, "GOOS="+o.GOOS)
+	}
+	if o.GOARCH != "" {
+		env = append(env, "GOARCH="+o.GOARCH)
+	}
+	return flags, env
+}
@@ -0,0 +1,218 @@
+package prelviz
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type Violation struct {
+	Rule           string                         `json:"rule"`
+	From           string                         `json:"from"`
+	To             string                         `json:"to"`
+	ImportUsageMap map[string]map[string]struct{} `json:"-"`
+	References     []*Reference                   `json:"references"`
+	// TestOnly is true when only tests make the dependency.
+	TestOnly bool `json:"test_only"`
+	// ImportTypes is the types of the imports which make the dependency. ex) normal, blank, dot, cgo
+	ImportTypes []string `json:"import_types"`
+}
+
+// Reference is an import spec or a selector usage which makes a dependency. Identifier is empty for an import spec.
+// Kind is the kind of the identifier, which is empty unless the packages are loaded with type information.
+type Reference struct {
+	ImportPath string `json:"import_path"`
+	Identifier string `json:"identifier,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Position
+}
+
+func (r *Reference) String() string {
+	if r.Identifier == "" {
+		return fmt.Sprintf("%s: import %q", r.Position, r.ImportPath)
+	}
+	if r.Kind != "" {
+		return fmt.Sprintf("%s: %s(%s)", r.Position, r.Identifier, r.Kind)
+	}
+	return fmt.Sprintf("%s: %s", r.Position, r.Identifier)
+}
+
+const (
+	CheckFormatText = "text"
+	CheckFormatJSON = "json"
+)
+
+var ruleDescriptions = map[string]string{
+	RuleNgRelation:      "ng relation",
+	RuleLayers:          "layers",
+	RuleAllowedRelation: "not allowed relation",
+}
+
+type checkReport struct {
+	Violations    []*Violation `json:"violations"`
+	Count         int          `json:"count"`
+	TestOnlyCount int          `json:"test_only_count"`
+}
+
+// Check writes every dependency which violates ng_relation, layers or allowed_relation to the output in the format and returns them.
+func (m *Prelviz) Check(format string) ([]*Violation, error) {
+	violations := m.violations()
+	switch format {
+	case CheckFormatJSON:
+		encoder := json.NewEncoder(m.output)
+		encoder.SetIndent("", "  ")
+		testOnlyCount := len(lo.Filter(violations, func(v *Violation, _ int) bool { return v.TestOnly }))
+		if err := encoder.Encode(&checkReport{Violations: violations, Count: len(violations), TestOnlyCount: testOnlyCount}); err != nil {
+			return nil, err
+		}
+	case CheckFormatText, "":
+		if err := m.writeCheckText(violations); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported check format: %s", format)
+	}
+	return violations, nil
+}
+
+func (m *Prelviz) writeCheckText(violations []*Violation) error {
+	testOnlyCount := 0
+	for _, v := range violations {
+		testOnlyLabel := ""
+		if v.TestOnly {
+			testOnlyLabel = " (test only)"
+			testOnlyCount++
+		}
+		if _, err := fmt.Fprintf(m.output, "%s%s: %s -> %s%s\n", ruleDescriptions[v.Rule], testOnlyLabel, v.From, v.To, importTypesLabel(v.ImportTypes)); err != nil {
+			return err
+		}
+		for _, importPath := range sortedKeys(v.ImportUsageMap) {
+			// blank and dot imports have no identifiers.
+			line := importPath
+			if usages := sortedKeys(v.ImportUsageMap[importPath]); len(usages) > 0 {
+				line = fmt.Sprintf("%s: %s", importPath, strings.Join(usages, ", "))
+			}
+			if _, err := fmt.Fprintf(m.output, "\t%s\n", line); err != nil {
+				return err
+			}
+		}
+		for _, r := range v.References {
+			if _, err := fmt.Fprintf(m.output, "\t\t%s\n", r); err != nil {
+				return err
+			}
+		}
+	}
+	if testOnlyCount > 0 {
+		_, err := fmt.Fprintf(m.output, "found %d violation(s), %d of them are test only\n", len(violations), testOnlyCount)
+		return err
+	}
+	if _, err := fmt.Fprintf(m.output, "found %d violation(s)\n", len(violations)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// violations returns the violations sorted by the relation. Test-only violations follow the others.
+func (m *Prelviz) violations() []*Violation {
+	violations := make([]*Violation, 0)
+	testOnlyRelationMap := m.nodeTestOnlyRelationMap()
+	for srcNodeName, relationMap := range m.nodeRelationCountMap() {
+		for dstNodeName := range relationMap {
+			rule := m.config.ViolatedRule(srcNodeName, dstNodeName)
+			if rule == "" {
+				continue
+			}
+			violations = append(violations, &Violation{
+				Rule:           rule,
+				From:           srcNodeName,
+				To:             dstNodeName,
+				ImportUsageMap: m.nodeImportUsageMap(srcNodeName, dstNodeName),
+				References:     m.nodeReferences(srcNodeName, dstNodeName),
+				TestOnly:       isTestOnlyRelation(testOnlyRelationMap, srcNodeName, dstNodeName),
+				ImportTypes:    m.nodeImportTypes(srcNodeName, dstNodeName),
+			})
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].TestOnly != violations[j].TestOnly {
+			return !violations[i].TestOnly
+		}
+		if violations[i].From != violations[j].From {
+			return violations[i].From < violations[j].From
+		}
+		return violations[i].To < violations[j].To
+	})
+	return violations
+}
+
+// nodeImportUsageMap returns the identifiers which packages in the src node use from packages in the dst node.
+func (m *Prelviz) nodeImportUsageMap(srcNodeName, dstNodeName string) map[string]map[string]struct{} {
+	importUsageMap := make(map[string]map[string]struct{})
+	m.walkNodeImports(srcNodeName, dstNodeName, func(importPath string, info *PackageInfo) {
+		if _, ok := importUsageMap[importPath]; !ok {
+			importUsageMap[importPath] = make(map[string]struct{})
+		}
+		for usage := range info.ImportUsageMap[importPath] {
+			importUsageMap[importPath][usage] = struct{}{}
+		}
+		for usage := range info.TestImportUsageMap[importPath] {
+			importUsageMap[importPath][usage] = struct{}{}
+		}
+	})
+	return importUsageMap
+}
+
+// nodeReferences returns the import specs and selector usages in the src node which refer to packages in the dst node.
+func (m *Prelviz) nodeReferences(srcNodeName, dstNodeName string) []*Reference {
+	references := make([]*Reference, 0)
+	m.walkNodeImports(srcNodeName, dstNodeName, func(importPath string, info *PackageInfo) {
+		for _, position := range info.ImportPositionMap[importPath] {
+			references = append(references, &Reference{ImportPath: importPath, Position: position})
+		}
+		for usage, positions := range info.UsagePositionMap[importPath] {
+			for _, position := range positions {
+				references = append(references, &Reference{ImportPath: importPath, Identifier: usage, Kind: info.UsageKindMap[importPath][usage], Position: position})
+			}
+		}
+	})
+	sort.Slice(references, func(i, j int) bool {
+		a, b := references[i], references[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return references
+}
+
+func (m *Prelviz) walkNodeImports(srcNodeName, dstNodeName string, fn func(importPath string, info *PackageInfo)) {
+	for pkgDirPath, info := range m.packageInfoMap {
+		if m.isExcludePackageWithDirPath(pkgDirPath) || m.nodeName(pkgDirPath) != srcNodeName {
+			continue
+		}
+		for _, importPath := range lo.Union(lo.Keys(info.ImportUsageMap), lo.Keys(info.TestImportUsageMap)) {
+			if !m.isNodePackage(importPath) || m.isExcludePackage(importPath) {
+				continue
+			}
+			if m.importPathNodeName(importPath) != dstNodeName {
+				continue
+			}
+			fn(importPath, info)
+		}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,221 @@
+package prelviz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPrelviz_violations(t *testing.T) {
+	type fields struct {
+		projectModuleName string
+		packageInfoMap    map[string]*PackageInfo
+		config            *Config
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   []*Violation
+	}{
+		{
+			name: "normal: ng_relation is not set",
+			fields: fields{
+				projectModuleName: "mod",
+				packageInfoMap: map[string]*PackageInfo{
+					"sample/src": {
+						Name:          "src",
+						DirectoryPath: "sample/src",
+						ImportUsageMap: map[string]map[string]struct{}{
+							"mod/sample/dst1": {"Sample1": {}},
+						},
+					},
+					"sample/dst1": {
+						Name:           "dst1",
+						DirectoryPath:  "sample/dst1",
+						ImportUsageMap: map[string]map[string]struct{}{},
+					},
+				},
+				config: &Config{
+					NgRelationMap:          make(map[string]map[string]struct{}),
+					GroupingDirectoryPaths: make([]string, 0),
+					ExcludePackageMap:      make(map[string]struct{}),
+				},
+			},
+			want: []*Violation{},
+		},
+		{
+			name: "normal: violation with grouping",
+			fields: fields{
+				projectModuleName: "mod",
+				packageInfoMap: map[string]*PackageInfo{
+					"sample/src": {
+						Name:          "src",
+						DirectoryPath: "sample/src",
+						ImportUsageMap: map[string]map[string]struct{}{
+							"mod/sample/grouping/dst1": {"Sample1": {}, "Sample2": {}},
+							"mod/sample/grouping/dst2": {"Sample3": {}},
+							"mod/sample/dst3":          {"Sample4": {}},
+							"fmt":                      {"Println": {}},
+						},
+					},
+					"sample/grouping/dst1": {
+						Name:           "dst1",
+						DirectoryPath:  "sample/grouping/dst1",
+						ImportUsageMap: map[string]map[string]struct{}{},
+					},
+					"sample/grouping/dst2": {
+						Name:           "dst2",
+						DirectoryPath:  "sample/grouping/dst2",
+						ImportUsageMap: map[string]map[string]struct{}{},
+					},
+					"sample/dst3": {
+						Name:           "dst3",
+						DirectoryPath:  "sample/dst3",
+						ImportUsageMap: map[string]map[string]struct{}{},
+					},
+				},
+				config: &Config{
+					NgRelationMap: map[string]map[string]struct{}{
+						"mod/sample/src": {"mod/sample/grouping": {}},
+					},
+					GroupingDirectoryPaths: []string{"sample/grouping"},
+					ExcludePackageMap:      make(map[string]struct{}),
+				},
+			},
+			want: []*Violation{
+				{
+					Rule: RuleNgRelation,
+					From: "mod/sample/src",
+					To:   "mod/sample/grouping",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"mod/sample/grouping/dst1": {"Sample1": {}, "Sample2": {}},
+						"mod/sample/grouping/dst2": {"Sample3": {}},
+					},
+					References:  []*Reference{},
+					ImportTypes: []string{},
+				},
+			},
+		},
+		{
+			name: "normal: test-only violations follow the others",
+			fields: fields{
+				projectModuleName: "mod",
+				packageInfoMap: map[string]*PackageInfo{
+					"sample/src": {
+						Name:          "src",
+						DirectoryPath: "sample/src",
+						ImportUsageMap: map[string]map[string]struct{}{
+							"mod/sample/dst1": {"Sample1": {}},
+						},
+						TestImportUsageMap: map[string]map[string]struct{}{
+							"mod/sample/dst1": {"Helper1": {}},
+							"mod/sample/dst2": {"Helper2": {}},
+						},
+					},
+					"sample/src_test": {
+						Name:          "src_test",
+						DirectoryPath: "sample/src",
+						ImportUsageMap: map[string]map[string]struct{}{
+							"mod/sample/dst3": {"Helper3": {}},
+						},
+						IsTest: true,
+					},
+				},
+				config: &Config{
+					NgRelationMap: map[string]map[string]struct{}{
+						"mod/sample/src":      {"mod/sample/dst1": {}, "mod/sample/dst2": {}},
+						"mod/sample/src_test": {"mod/sample/dst3": {}},
+					},
+					GroupingDirectoryPaths: make([]string, 0),
+					ExcludePackageMap:      make(map[string]struct{}),
+				},
+			},
+			want: []*Violation{
+				{
+					Rule: RuleNgRelation,
+					From: "mod/sample/src",
+					To:   "mod/sample/dst1",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"mod/sample/dst1": {"Sample1": {}, "Helper1": {}},
+					},
+					References:  []*Reference{},
+					ImportTypes: []string{},
+				},
+				{
+					Rule: RuleNgRelation,
+					From: "mod/sample/src",
+					To:   "mod/sample/dst2",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"mod/sample/dst2": {"Helper2": {}},
+					},
+					References:  []*Reference{},
+					TestOnly:    true,
+					ImportTypes: []string{},
+				},
+				{
+					Rule: RuleNgRelation,
+					From: "mod/sample/src_test",
+					To:   "mod/sample/dst3",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"mod/sample/dst3": {"Helper3": {}},
+					},
+					References:  []*Reference{},
+					TestOnly:    true,
+					ImportTypes: []string{},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Prelviz{
+				projectModuleName: tt.fields.projectModuleName,
+				packageInfoMap:    tt.fields.packageInfoMap,
+				config:            tt.fields.config,
+			}
+			if got := m.violations(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Prelviz.violations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrelviz_Check(t *testing.T) {
+	output := new(bytes.Buffer)
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"sample/src": {
+				Name:          "src",
+				DirectoryPath: "sample/src",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/sample/dst1": {"Sample2": {}, "Sample1": {}},
+				},
+			},
+			"sample/dst1": {
+				Name:           "dst1",
+				DirectoryPath:  "sample/dst1",
+				ImportUsageMap: map[string]map[string]struct{}{},
+			},
+		},
+		config: &Config{
+			NgRelationMap: map[string]map[string]struct{}{
+				"mod/sample/src": {"mod/sample/dst1": {}},
+			},
+			GroupingDirectoryPaths: make([]string, 0),
+			ExcludePackageMap:      make(map[string]struct{}),
+		},
+		output: output,
+	}
+	violations, err := m.Check(CheckFormatText)
+	if err != nil {
+		t.Fatalf("Prelviz.Check() error = %v", err)
+	}
+	if len(violations) != 1 {
+		t.Errorf("Prelviz.Check() violations = %d, want 1", len(violations))
+	}
+	want := "ng relation: mod/sample/src -> mod/sample/dst1\n\tmod/sample/dst1: Sample1, Sample2\nfound 1 violation(s)\n"
+	if got := output.String(); got != want {
+		t.Errorf("Prelviz.Check() output = %q, want %q", got, want)
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/kazdevl/prelviz"
 )
@@ -11,19 +13,56 @@ var (
 	projectDirectoryPath string
 	outputFilePath       string
 	dotLayout            string
+	format               string
+	checkFormat          string
+	addr                 string
+	loader               string
+	tags                 string
+	goos                 string
+	goarch               string
+	includeTests         bool
+	skipGenerated        bool
+	external             string
+	stdlib               string
+	label                string
+	from                 string
+	to                   string
+	level                string
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		check(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "edge" {
+		edge(os.Args[2:])
+		return
+	}
+
 	flag.StringVar(&projectDirectoryPath, "i", "", `requreid: "true", description: "input project directory path"`)
 	flag.StringVar(&outputFilePath, "o", "", `requreid: "false", description: "output file path(default is stdout)"`)
 	flag.StringVar(&dotLayout, "l", "dot", `requreid: "false", description: "dot layout. ex) dot, neato, fdp, sfdp, twopi, circo"`)
+	flag.StringVar(&label, "label", prelviz.LabelIdentifiers, `requreid: "false", description: "count on edges. ex) identifiers, references, files (distinct identifiers used, references to them, or files importing the packages)"`)
+	flag.StringVar(&format, "format", "", `requreid: "false", description: "output format. ex) dot, json, mermaid, plantuml, svg, png, html (default is detected by the extension of output file path, or dot)"`)
+	flag.StringVar(&level, "level", prelviz.LevelPackage, `requreid: "false", description: "granularity of nodes. ex) package, type (type draws exported types and functions in dot, json or mermaid)"`)
+	addLoadFlags(flag.CommandLine)
 	flag.Parse()
 
 	if projectDirectoryPath == "" {
 		log.Fatal("project directory path is required")
 	}
 
-	prelviz, err := prelviz.NewPrelviz(projectDirectoryPath, outputFilePath, dotLayout)
+	option := loadOption()
+	option.DotLayout = dotLayout
+	option.Format = format
+	option.Label = label
+	option.Level = level
+	prelviz, err := prelviz.NewPrelviz(projectDirectoryPath, outputFilePath, option)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -31,3 +70,125 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+func check(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	fs.StringVar(&projectDirectoryPath, "i", "", `requreid: "true", description: "input project directory path"`)
+	fs.StringVar(&outputFilePath, "o", "", `requreid: "false", description: "output file path(default is stdout)"`)
+	fs.StringVar(&checkFormat, "format", prelviz.CheckFormatText, `requreid: "false", description: "report format. ex) text, json"`)
+	addLoadFlags(fs)
+	_ = fs.Parse(args)
+
+	if projectDirectoryPath == "" {
+		log.Fatal("project directory path is required")
+	}
+
+	prelviz, err := prelviz.NewPrelviz(projectDirectoryPath, outputFilePath, loadOption())
+	if err != nil {
+		log.Fatal(err)
+	}
+	violations, err := prelviz.Check(checkFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(violations) > 0 {
+		os.Exit(1)
+	}
+}
+
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.StringVar(&projectDirectoryPath, "i", "", `requreid: "true", description: "input project directory path"`)
+	fs.StringVar(&dotLayout, "l", "dot", `requreid: "false", description: "dot layout. ex) dot, neato, fdp, sfdp, twopi, circo"`)
+	fs.StringVar(&label, "label", prelviz.LabelIdentifiers, `requreid: "false", description: "count on edges. ex) identifiers, references, files (distinct identifiers used, references to them, or files importing the packages)"`)
+	fs.StringVar(&addr, "addr", "localhost:8080", `requreid: "false", description: "address which the server listens on"`)
+	fs.StringVar(&format, "format", prelviz.FormatHTML, `requreid: "false", description: "report format. ex) html, svg, png, dot, json, mermaid, plantuml (only html reloads itself in the browser)"`)
+	fs.StringVar(&level, "level", prelviz.LevelPackage, `requreid: "false", description: "granularity of nodes. ex) package, type (type draws exported types and functions in dot, json or mermaid)"`)
+	addLoadFlags(fs)
+	_ = fs.Parse(args)
+
+	if projectDirectoryPath == "" {
+		log.Fatal("project directory path is required")
+	}
+
+	option := loadOption()
+	option.DotLayout = dotLayout
+	option.Format = format
+	option.Label = label
+	option.Level = level
+	server, err := prelviz.NewServer(projectDirectoryPath, option)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("serving the package relation of %s on http://%s", projectDirectoryPath, addr)
+	if err = server.ListenAndServe(addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func edge(args []string) {
+	fs := flag.NewFlagSet("edge", flag.ExitOnError)
+	fs.StringVar(&projectDirectoryPath, "i", "", `requreid: "true", description: "input project directory path"`)
+	fs.StringVar(&outputFilePath, "o", "", `requreid: "false", description: "output file path(default is stdout)"`)
+	fs.StringVar(&from, "from", "", `requreid: "true", description: "node name which references the symbols. ex) github.com/kazdevl/sample_project/app/usecase"`)
+	fs.StringVar(&to, "to", "", `requreid: "true", description: "node name whose symbols are referenced. ex) github.com/kazdevl/sample_project/app/domain"`)
+	fs.StringVar(&format, "format", "", `requreid: "false", description: "output format. ex) dot, json, mermaid (default is detected by the extension of output file path, or dot)"`)
+	addLoadFlags(fs)
+	_ = fs.Parse(args)
+
+	if projectDirectoryPath == "" {
+		log.Fatal("project directory path is required")
+	}
+	if from == "" || to == "" {
+		log.Fatal("from and to are required")
+	}
+
+	option := loadOption()
+	option.Format = format
+	prelviz, err := prelviz.NewPrelviz(projectDirectoryPath, outputFilePath, option)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = prelviz.Edge(from, to); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// addLoadFlags adds the flags which select and load the packages, shared by the commands.
+func addLoadFlags(fs *flag.FlagSet) {
+	fs.StringVar(&loader, "loader", prelviz.LoaderAST, `requreid: "false", description: "package loader. ex) ast, packages (packages resolves import paths by go/packages and falls back to ast on failure, but takes seconds to type check the dependencies)"`)
+	fs.StringVar(&tags, "tags", "", `requreid: "false", description: "comma-separated build tags which select go files like go build"`)
+	fs.StringVar(&goos, "goos", "", `requreid: "false", description: "GOOS which selects go files like go build"`)
+	fs.StringVar(&goarch, "goarch", "", `requreid: "false", description: "GOARCH which selects go files like go build"`)
+	fs.BoolVar(&includeTests, "tests", false, `requreid: "false", description: "include _test.go files. dependencies only from tests are drawn as dashed edges"`)
+	fs.BoolVar(&skipGenerated, "skip-generated", false, `requreid: "false", description: "skip generated files which have the comment // Code generated ... DO NOT EDIT."`)
+	fs.StringVar(&external, "external", prelviz.ExternalNone, `requreid: "false", description: "draw imported packages out of the project. ex) none, package, module (module collapses packages of a module required by go.mod)"`)
+	fs.StringVar(&stdlib, "stdlib", "", `requreid: "false", description: "draw imported packages of the standard library. ex) none, node, annotation (default follows -external)"`)
+}
+
+// loadOption returns the option of the flags added by addLoadFlags. The commands set the rest of the option.
+func loadOption() *prelviz.Option {
+	return &prelviz.Option{
+		Loader:        loader,
+		Build:         buildOption(),
+		IncludeTests:  includeTests,
+		SkipGenerated: skipGenerated,
+		External:      external,
+		Stdlib:        stdlib,
+	}
+}
+
+// buildOption returns the build constraints of the flags, or nil to load the go files of the running environment when no flag is set.
+func buildOption() *prelviz.BuildOption {
+	if tags == "" && goos == "" && goarch == "" {
+		return nil
+	}
+	option := &prelviz.BuildOption{
+		GOOS:   goos,
+		GOARCH: goarch,
+	}
+	if tags != "" {
+		option.Tags = strings.Split(tags, ",")
+	}
+	return option
+}
@@ -1,7 +1,9 @@
 package prelviz
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,22 +17,74 @@ type ConfigBinder struct {
 	GroupingDirectoryPaths []string     `json:"grouping_directory_path"`
 	ExcludePackages        []string     `json:"exclude_package"`
 	ExcludeDirectoryPaths  []string     `json:"exclude_directory_path"`
+	Layers                 []LayerRank  `json:"layers"`
+	AllowedRelations       []NgRelation `json:"allowed_relation"`
 }
 
 type Config struct {
 	NgRelationMap          map[string]map[string]struct{}
 	GroupingDirectoryPaths []string
 	ExcludePackageMap      map[string]struct{}
+	Layers                 []*PackageLayer
+	AllowedRelationMap     map[string]map[string]struct{}
 }
 
+// NgRelation is a relation between packages. In ng_relation, to is the packages which from must not depend on.
+// In allowed_relation, to is the only packages which from may depend on.
 type NgRelation struct {
 	From string   `json:"from"`
 	To   []string `json:"to"`
 }
 
+// Layer is a layer of the architecture. Layers are listed from the top and the directory paths can be patterns.
+type Layer struct {
+	Name           string   `json:"name"`
+	DirectoryPaths []string `json:"directory_path"`
+}
+
+// LayerRank is the layers on the same rank. An entry of layers in the config is a layer, or an array of layers
+// which are side by side, such as usecase and infra which both depend on domain.
+type LayerRank []Layer
+
+func (r *LayerRank) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var layers []Layer
+		if err := json.Unmarshal(data, &layers); err != nil {
+			return err
+		}
+		*r = layers
+		return nil
+	}
+	var layer Layer
+	if err := json.Unmarshal(data, &layer); err != nil {
+		return err
+	}
+	*r = LayerRank{layer}
+	return nil
+}
+
+// PackageLayer is Layer whose directory paths are converted to package patterns. Rank is the index of the entry in layers.
+type PackageLayer struct {
+	Name            string
+	PackagePatterns []string
+	Rank            int
+}
+
+const (
+	RuleNgRelation      = "ng_relation"
+	RuleLayers          = "layers"
+	RuleAllowedRelation = "allowed_relation"
+)
+
 const configJsonName = ".prelviz.config.json"
 
 func NewConfig(path, moduleName string) (*Config, error) {
+	return NewWorkspaceConfig(path, NewModuleWorkspace(moduleName))
+}
+
+// NewWorkspaceConfig reads the config at the project directory. Directory paths in the config are relative to the project directory
+// and converted to the package paths of the modules which they belong to.
+func NewWorkspaceConfig(path string, workspace *Workspace) (*Config, error) {
 	filePath := filepath.Join(path, configJsonName)
 	if !fileExists(filePath) {
 		return &Config{
@@ -49,7 +103,7 @@ func NewConfig(path, moduleName string) (*Config, error) {
 		return nil, err
 	}
 
-	c, err := cb.ToConfig(path, moduleName)
+	c, err := cb.ToWorkspaceConfig(path, workspace)
 	if err != nil {
 		return nil, err
 	}
@@ -57,6 +111,10 @@ func NewConfig(path, moduleName string) (*Config, error) {
 }
 
 func (c ConfigBinder) ToConfig(path, moduleName string) (*Config, error) {
+	return c.ToWorkspaceConfig(path, NewModuleWorkspace(moduleName))
+}
+
+func (c ConfigBinder) ToWorkspaceConfig(path string, workspace *Workspace) (*Config, error) {
 	conf := &Config{
 		NgRelationMap:          make(map[string]map[string]struct{}),
 		GroupingDirectoryPaths: make([]string, 0),
@@ -64,16 +122,11 @@ func (c ConfigBinder) ToConfig(path, moduleName string) (*Config, error) {
 	}
 
 	if c.NgRelations != nil {
-		m := make(map[string]map[string]struct{})
-		for _, ngRelation := range c.NgRelations {
-			if _, ok := m[ngRelation.From]; !ok {
-				m[ngRelation.From] = make(map[string]struct{})
-			}
-			for _, to := range ngRelation.To {
-				m[ngRelation.From][to] = struct{}{}
-			}
-		}
-		conf.NgRelationMap = m
+		conf.NgRelationMap = relationMap(c.NgRelations)
+	}
+
+	if c.AllowedRelations != nil {
+		conf.AllowedRelationMap = relationMap(c.AllowedRelations)
 	}
 
 	if c.ExcludePackages != nil {
@@ -99,7 +152,7 @@ func (c ConfigBinder) ToConfig(path, moduleName string) (*Config, error) {
 				}
 				dirPathFromProjectRoot := strings.TrimPrefix(nowPath, path)
 				if info.IsDir() {
-					conf.ExcludePackageMap[filepath.Join(moduleName, dirPathFromProjectRoot)] = struct{}{}
+					conf.ExcludePackageMap[workspace.PackagePath(dirPathFromProjectRoot)] = struct{}{}
 				}
 				return nil
 			}); err != nil {
@@ -108,6 +161,29 @@ func (c ConfigBinder) ToConfig(path, moduleName string) (*Config, error) {
 		}
 	}
 
+	if c.Layers != nil {
+		rank := 0
+		for _, layers := range c.Layers {
+			if len(layers) == 0 {
+				continue
+			}
+			for _, layer := range layers {
+				if layer.Name == "" {
+					return nil, errors.New("layer name is required")
+				}
+				patterns := make([]string, 0, len(layer.DirectoryPaths))
+				for _, dir := range layer.DirectoryPaths {
+					if dir == "" {
+						continue
+					}
+					patterns = append(patterns, workspace.PackagePath(dir))
+				}
+				conf.Layers = append(conf.Layers, &PackageLayer{Name: layer.Name, PackagePatterns: patterns, Rank: rank})
+			}
+			rank++
+		}
+	}
+
 	if c.GroupingDirectoryPaths != nil {
 		for _, groupDirPath := range c.GroupingDirectoryPaths {
 			if groupDirPath == "" {
@@ -159,14 +235,103 @@ func (c *Config) IsExcludePackage(pkg string) bool {
 	return false
 }
 
+// IsNgRelation reports whether the dependency from -> to matches ng_relation.
+// from and to in ng_relation can be patterns, see matchPattern.
 func (c *Config) IsNgRelation(from string, to string) bool {
-	if _, ok := c.NgRelationMap[from]; !ok {
-		return false
+	if _, ok := c.NgRelationMap[from][to]; ok {
+		return true
+	}
+	for fromPattern, toPatternSet := range c.NgRelationMap {
+		if !matchNegatablePattern(fromPattern, from) {
+			continue
+		}
+		if matchPatternSet(toPatternSet, to) {
+			return true
+		}
 	}
-	if _, ok := c.NgRelationMap[from][to]; !ok {
+	return false
+}
+
+// IsNotAllowedRelation reports whether from matches allowed_relation and to matches none of the allowed packages of from.
+func (c *Config) IsNotAllowedRelation(from string, to string) bool {
+	restricted := false
+	for fromPattern, toPatternSet := range c.AllowedRelationMap {
+		if !matchNegatablePattern(fromPattern, from) {
+			continue
+		}
+		if matchPatternSet(toPatternSet, to) {
+			return false
+		}
+		restricted = true
+	}
+	return restricted
+}
+
+// LayerIndex returns the index of the first layer which the package belongs to, or -1 if it belongs to no layer.
+func (c *Config) LayerIndex(pkg string) int {
+	for i, layer := range c.Layers {
+		for _, pattern := range layer.PackagePatterns {
+			if matchPattern(pattern, pkg) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// LayerRank returns the rank of the layer which the package belongs to, or -1 if it belongs to no layer.
+func (c *Config) LayerRank(pkg string) int {
+	layerIndex := c.LayerIndex(pkg)
+	if layerIndex < 0 {
+		return -1
+	}
+	return c.Layers[layerIndex].Rank
+}
+
+// LayerRankNum returns the number of the ranks of the layers.
+func (c *Config) LayerRankNum() int {
+	if len(c.Layers) == 0 {
+		return 0
+	}
+	return c.Layers[len(c.Layers)-1].Rank + 1
+}
+
+// IsLayerViolation reports whether the dependency from -> to goes up the layers, skips a rank
+// or goes to another layer on the same rank.
+func (c *Config) IsLayerViolation(from string, to string) bool {
+	fromIndex, toIndex := c.LayerIndex(from), c.LayerIndex(to)
+	if fromIndex < 0 || toIndex < 0 || fromIndex == toIndex {
 		return false
 	}
-	return true
+	fromRank, toRank := c.Layers[fromIndex].Rank, c.Layers[toIndex].Rank
+	return toRank <= fromRank || toRank > fromRank+1
+}
+
+// ViolatedRule returns the rule which the dependency from -> to violates, or an empty string if it violates nothing.
+func (c *Config) ViolatedRule(from string, to string) string {
+	if c.IsNgRelation(from, to) {
+		return RuleNgRelation
+	}
+	if c.IsLayerViolation(from, to) {
+		return RuleLayers
+	}
+	if c.IsNotAllowedRelation(from, to) {
+		return RuleAllowedRelation
+	}
+	return ""
+}
+
+func relationMap(relations []NgRelation) map[string]map[string]struct{} {
+	m := make(map[string]map[string]struct{})
+	for _, relation := range relations {
+		if _, ok := m[relation.From]; !ok {
+			m[relation.From] = make(map[string]struct{})
+		}
+		for _, to := range relation.To {
+			m[relation.From][to] = struct{}{}
+		}
+	}
+	return m
 }
 
 func fileExists(filePath string) bool {
@@ -48,6 +48,25 @@ func TestNewConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "normal: layers on the same rank",
+			args: args{
+				path:       "testdata/config_test/layers",
+				moduleName: "mod",
+			},
+			want: &Config{
+				NgRelationMap:          make(map[string]map[string]struct{}),
+				GroupingDirectoryPaths: make([]string, 0),
+				ExcludePackageMap:      make(map[string]struct{}),
+				Layers: []*PackageLayer{
+					{Name: "handler", PackagePatterns: []string{"mod/app/handler/..."}, Rank: 0},
+					{Name: "usecase", PackagePatterns: []string{"mod/app/usecase/..."}, Rank: 1},
+					{Name: "infra", PackagePatterns: []string{"mod/app/infra/..."}, Rank: 1},
+					{Name: "domain", PackagePatterns: []string{"mod/app/domain/..."}, Rank: 2},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -69,6 +88,8 @@ func TestConfigBinder_ToConfig(t *testing.T) {
 		GroupingDirectoryPaths []string
 		ExcludePackages        []string
 		ExcludeDirectorys      []string
+		Layers                 []LayerRank
+		AllowedRelations       []NgRelation
 	}
 	type args struct {
 		path       string
@@ -371,6 +392,85 @@ func TestConfigBinder_ToConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "normal: layers is set",
+			fields: fields{
+				Layers: []LayerRank{
+					{{Name: "usecase", DirectoryPaths: []string{"app/usecase"}}},
+					{{Name: "domain", DirectoryPaths: []string{"app/domain/...", ""}}},
+				},
+			},
+			args: args{moduleName: "mod"},
+			want: &Config{
+				NgRelationMap:          make(map[string]map[string]struct{}),
+				GroupingDirectoryPaths: make([]string, 0),
+				ExcludePackageMap:      make(map[string]struct{}),
+				Layers: []*PackageLayer{
+					{Name: "usecase", PackagePatterns: []string{"mod/app/usecase"}, Rank: 0},
+					{Name: "domain", PackagePatterns: []string{"mod/app/domain/..."}, Rank: 1},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: layers on the same rank",
+			fields: fields{
+				Layers: []LayerRank{
+					{{Name: "handler", DirectoryPaths: []string{"app/handler"}}},
+					{},
+					{
+						{Name: "usecase", DirectoryPaths: []string{"app/usecase"}},
+						{Name: "infra", DirectoryPaths: []string{"app/infra/..."}},
+					},
+					{{Name: "domain", DirectoryPaths: []string{"app/domain/..."}}},
+				},
+			},
+			args: args{moduleName: "mod"},
+			want: &Config{
+				NgRelationMap:          make(map[string]map[string]struct{}),
+				GroupingDirectoryPaths: make([]string, 0),
+				ExcludePackageMap:      make(map[string]struct{}),
+				Layers: []*PackageLayer{
+					{Name: "handler", PackagePatterns: []string{"mod/app/handler"}, Rank: 0},
+					{Name: "usecase", PackagePatterns: []string{"mod/app/usecase"}, Rank: 1},
+					{Name: "infra", PackagePatterns: []string{"mod/app/infra/..."}, Rank: 1},
+					{Name: "domain", PackagePatterns: []string{"mod/app/domain/..."}, Rank: 2},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: allowed_relation is set",
+			fields: fields{
+				AllowedRelations: []NgRelation{
+					{
+						From: "mod/sample1",
+						To:   []string{"mod/sample2", "mod/sample3"},
+					},
+				},
+			},
+			args: args{moduleName: "mod"},
+			want: &Config{
+				NgRelationMap:          make(map[string]map[string]struct{}),
+				GroupingDirectoryPaths: make([]string, 0),
+				ExcludePackageMap:      make(map[string]struct{}),
+				AllowedRelationMap: map[string]map[string]struct{}{
+					"mod/sample1": {"mod/sample2": {}, "mod/sample3": {}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "anomaly: layer name is not set",
+			fields: fields{
+				Layers: []LayerRank{
+					{{DirectoryPaths: []string{"app/usecase"}}},
+				},
+			},
+			args:    args{moduleName: "mod"},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -379,6 +479,8 @@ func TestConfigBinder_ToConfig(t *testing.T) {
 				GroupingDirectoryPaths: tt.fields.GroupingDirectoryPaths,
 				ExcludePackages:        tt.fields.ExcludePackages,
 				ExcludeDirectoryPaths:  tt.fields.ExcludeDirectorys,
+				Layers:                 tt.fields.Layers,
+				AllowedRelations:       tt.fields.AllowedRelations,
 			}
 			got, err := c.ToConfig(tt.args.path, tt.args.moduleName)
 			if (err != nil) != tt.wantErr {
@@ -648,6 +750,45 @@ func TestConfig_IsNgRelation(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "normal: from and to match patterns",
+			fields: fields{
+				NgRelationMap: map[string]map[string]struct{}{
+					".../app/domain/...": {".../app/infra/...": {}},
+				},
+			},
+			args: args{
+				from: "mod/app/domain/model",
+				to:   "mod/app/infra/tmpmemory",
+			},
+			want: true,
+		},
+		{
+			name: "normal: negated from pattern matches",
+			fields: fields{
+				NgRelationMap: map[string]map[string]struct{}{
+					"!mod/app/usecase": {"mod/app/infra/...": {}},
+				},
+			},
+			args: args{
+				from: "mod/app/usecase",
+				to:   "mod/app/infra/tmpmemory",
+			},
+			want: false,
+		},
+		{
+			name: "normal: negated to pattern matches",
+			fields: fields{
+				NgRelationMap: map[string]map[string]struct{}{
+					"mod/app/domain/*": {"mod/app/...": {}, "!mod/app/domain/...": {}},
+				},
+			},
+			args: args{
+				from: "mod/app/domain/model",
+				to:   "mod/app/domain/entity",
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -660,3 +801,158 @@ func TestConfig_IsNgRelation(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_IsLayerViolation(t *testing.T) {
+	layers := []*PackageLayer{
+		{Name: "handler", PackagePatterns: []string{"mod/app/handler"}, Rank: 0},
+		{Name: "usecase", PackagePatterns: []string{"mod/app/usecase/..."}, Rank: 1},
+		{Name: "infra", PackagePatterns: []string{"mod/app/infra/..."}, Rank: 1},
+		{Name: "domain", PackagePatterns: []string{"mod/app/domain/..."}, Rank: 2},
+	}
+	type args struct {
+		from string
+		to   string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "normal: to the next layer",
+			args: args{from: "mod/app/handler", to: "mod/app/usecase/sample"},
+			want: false,
+		},
+		{
+			name: "normal: in the same layer",
+			args: args{from: "mod/app/domain/model", to: "mod/app/domain/entity"},
+			want: false,
+		},
+		{
+			name: "normal: to the upper layer",
+			args: args{from: "mod/app/domain/model", to: "mod/app/usecase"},
+			want: true,
+		},
+		{
+			name: "normal: skip a layer",
+			args: args{from: "mod/app/handler", to: "mod/app/domain/model"},
+			want: true,
+		},
+		{
+			name: "normal: to the next rank from a layer side by side",
+			args: args{from: "mod/app/infra/db", to: "mod/app/domain/model"},
+			want: false,
+		},
+		{
+			name: "normal: to another layer on the same rank",
+			args: args{from: "mod/app/usecase", to: "mod/app/infra/db"},
+			want: true,
+		},
+		{
+			name: "normal: package do not belong to layers",
+			args: args{from: "mod/app/util", to: "mod/app/handler"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{
+				Layers: layers,
+			}
+			if got := c.IsLayerViolation(tt.args.from, tt.args.to); got != tt.want {
+				t.Errorf("IsLayerViolation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_IsNotAllowedRelation(t *testing.T) {
+	type fields struct {
+		AllowedRelationMap map[string]map[string]struct{}
+	}
+	type args struct {
+		from string
+		to   string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   bool
+	}{
+		{
+			name: "normal: AllowedRelationMap is nil",
+			fields: fields{
+				AllowedRelationMap: nil,
+			},
+			args: args{
+				from: "mod/app/usecase",
+				to:   "mod/app/infra",
+			},
+			want: false,
+		},
+		{
+			name: "normal: from do not exists",
+			fields: fields{
+				AllowedRelationMap: map[string]map[string]struct{}{
+					"mod/app/domain/...": {"mod/app/domain/...": {}},
+				},
+			},
+			args: args{
+				from: "mod/app/usecase",
+				to:   "mod/app/infra",
+			},
+			want: false,
+		},
+		{
+			name: "normal: to is allowed",
+			fields: fields{
+				AllowedRelationMap: map[string]map[string]struct{}{
+					"mod/app/usecase": {"mod/app/domain/...": {}},
+				},
+			},
+			args: args{
+				from: "mod/app/usecase",
+				to:   "mod/app/domain/model",
+			},
+			want: false,
+		},
+		{
+			name: "normal: to is not allowed",
+			fields: fields{
+				AllowedRelationMap: map[string]map[string]struct{}{
+					"mod/app/usecase": {"mod/app/domain/...": {}},
+				},
+			},
+			args: args{
+				from: "mod/app/usecase",
+				to:   "mod/app/infra",
+			},
+			want: true,
+		},
+		{
+			name: "normal: to is allowed by another matched from",
+			fields: fields{
+				AllowedRelationMap: map[string]map[string]struct{}{
+					"mod/app/usecase": {"mod/app/domain/...": {}},
+					"mod/app/...":     {"mod/app/util": {}},
+				},
+			},
+			args: args{
+				from: "mod/app/usecase",
+				to:   "mod/app/util",
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{
+				AllowedRelationMap: tt.fields.AllowedRelationMap,
+			}
+			if got := c.IsNotAllowedRelation(tt.args.from, tt.args.to); got != tt.want {
+				t.Errorf("IsNotAllowedRelation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
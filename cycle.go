@@ -0,0 +1,130 @@
+package prelviz
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cycles returns the strongly connected components which have two or more nodes in the node relation graph.
+// Each component and the components are sorted. The relations only from tests are ignored, because tests can not make import cycles.
+func (m *Prelviz) cycles() [][]string {
+	return stronglyConnectedComponents(m.nodeRelationCountMapOf(false))
+}
+
+// writeCycles writes a cycle path of every cyclic component to the error output.
+func (m *Prelviz) writeCycles(cycles [][]string) error {
+	if m.errOutput == nil {
+		return nil
+	}
+	relationMap := m.nodeRelationCountMapOf(false)
+	for _, component := range cycles {
+		path := cyclePath(relationMap, component)
+		if _, err := fmt.Fprintf(m.errOutput, "import cycle: %s\n", strings.Join(path, " -> ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stronglyConnectedComponents finds cyclic components by Tarjan's algorithm.
+func stronglyConnectedComponents(relationMap map[string]map[string]int) [][]string {
+	var (
+		index    int
+		indexMap = make(map[string]int)
+		lowMap   = make(map[string]int)
+		onStack  = make(map[string]bool)
+		stack    = make([]string, 0)
+		results  = make([][]string, 0)
+	)
+
+	var connect func(node string)
+	connect = func(node string) {
+		indexMap[node] = index
+		lowMap[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range sortedKeys(relationMap[node]) {
+			if _, ok := indexMap[next]; !ok {
+				connect(next)
+				lowMap[node] = min(lowMap[node], lowMap[next])
+			} else if onStack[next] {
+				lowMap[node] = min(lowMap[node], indexMap[next])
+			}
+		}
+
+		if lowMap[node] != indexMap[node] {
+			return
+		}
+		component := make([]string, 0)
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			results = append(results, component)
+		}
+	}
+
+	for _, node := range sortedKeys(relationMap) {
+		if _, ok := indexMap[node]; !ok {
+			connect(node)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i][0] < results[j][0]
+	})
+	return results
+}
+
+// cyclePath returns the shortest cycle from the first node of the component back to itself.
+func cyclePath(relationMap map[string]map[string]int, component []string) []string {
+	inComponent := make(map[string]bool)
+	for _, node := range component {
+		inComponent[node] = true
+	}
+	start := component[0]
+	prevMap := map[string]string{start: ""}
+	queue := []string{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range sortedKeys(relationMap[node]) {
+			if !inComponent[next] {
+				continue
+			}
+			if next == start {
+				path := []string{start}
+				for n := node; n != start; n = prevMap[n] {
+					path = append([]string{n}, path...)
+				}
+				return append([]string{start}, path...)
+			}
+			if _, ok := prevMap[next]; ok {
+				continue
+			}
+			prevMap[next] = node
+			queue = append(queue, next)
+		}
+	}
+	return component
+}
+
+// cyclicNodeMap returns the component index of every node in the cycles.
+func cyclicNodeMap(cycles [][]string) map[string]int {
+	nodeMap := make(map[string]int)
+	for i, component := range cycles {
+		for _, node := range component {
+			nodeMap[node] = i
+		}
+	}
+	return nodeMap
+}
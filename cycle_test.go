@@ -0,0 +1,84 @@
+package prelviz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_stronglyConnectedComponents(t *testing.T) {
+	type args struct {
+		relationMap map[string]map[string]int
+	}
+	tests := []struct {
+		name string
+		args args
+		want [][]string
+	}{
+		{
+			name: "normal: no cycle",
+			args: args{
+				relationMap: map[string]map[string]int{
+					"mod/a": {"mod/b": 1, "mod/c": 1},
+					"mod/b": {"mod/c": 1},
+				},
+			},
+			want: [][]string{},
+		},
+		{
+			name: "normal: cycles",
+			args: args{
+				relationMap: map[string]map[string]int{
+					"mod/a": {"mod/b": 1},
+					"mod/b": {"mod/c": 1},
+					"mod/c": {"mod/a": 1, "mod/d": 1},
+					"mod/d": {"mod/e": 1},
+					"mod/e": {"mod/d": 1},
+					"mod/f": {"mod/a": 1},
+				},
+			},
+			want: [][]string{
+				{"mod/a", "mod/b", "mod/c"},
+				{"mod/d", "mod/e"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stronglyConnectedComponents(tt.args.relationMap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stronglyConnectedComponents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cyclePath(t *testing.T) {
+	type args struct {
+		relationMap map[string]map[string]int
+		component   []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "normal: shortest cycle",
+			args: args{
+				relationMap: map[string]map[string]int{
+					"mod/a": {"mod/b": 1, "mod/c": 1},
+					"mod/b": {"mod/c": 1},
+					"mod/c": {"mod/a": 1},
+				},
+				component: []string{"mod/a", "mod/b", "mod/c"},
+			},
+			want: []string{"mod/a", "mod/c", "mod/a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cyclePath(tt.args.relationMap, tt.args.component); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cyclePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
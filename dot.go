@@ -0,0 +1,252 @@
+package prelviz
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/awalterschulze/gographviz"
+	"github.com/samber/lo"
+)
+
+// layerFillColors are the node colors of layers in the spectral11 color scheme.
+var layerFillColors = []string{"11", "1", "3", "5", "8", "2", "4"}
+
+func (m *Prelviz) writeDot(cycles [][]string) error {
+	// add graph
+	var (
+		graphDefaultAttrs = map[string]string{
+			"charset":   `"UTF-8"`,
+			"label":     `"package relation"`,
+			"labelloc":  `"t"`,
+			"labeljust": `"c"`,
+			"bgcolor":   `"#343434"`,
+			"fontsize":  "18",
+			"fontcolor": `"white"`,
+			"style":     `"filled"`,
+			"rankdir":   `"TB"`,
+			"margin":    "0.5",
+			"layout":    fmt.Sprintf(`"%s"`, m.dotLayout),
+		}
+		nodeDefaultAttrs = map[string]string{
+			"shape":       `"record"`,
+			"style":       `"solid,filled"`,
+			"fontcolor":   "6",
+			"fontsize":    "14",
+			"color":       "7",
+			"colorscheme": `"spectral11"`,
+		}
+	)
+	graphAst, _ := gographviz.ParseString(`digraph d {}`)
+	graph := gographviz.NewGraph()
+	if err := gographviz.Analyse(graphAst, graph); err != nil {
+		return err
+	}
+	graphAttrs, err := gographviz.NewAttrs(graphDefaultAttrs)
+	if err != nil {
+		return err
+	}
+	graph.Attrs.Extend(graphAttrs)
+
+	// add layer
+	for i := 0; i < m.config.LayerRankNum(); i++ {
+		if err = graph.AddSubGraph(graph.Name, m.layerSubGraphName(i), map[string]string{"rank": `"same"`}); err != nil {
+			return err
+		}
+	}
+
+	// add module cluster
+	moduleSubGraphNameMap := make(map[string]string)
+	if workspace := m.moduleWorkspace(); workspace.IsMultiModule() {
+		for i, module := range workspace.Modules {
+			subGraphName := m.moduleSubGraphName(i)
+			if err = graph.AddSubGraph(graph.Name, subGraphName, map[string]string{
+				"label":     fmt.Sprintf(`"module: %s"`, module.Path),
+				"style":     `"dashed"`,
+				"color":     `"white"`,
+				"fontcolor": `"white"`,
+			}); err != nil {
+				return err
+			}
+			moduleSubGraphNameMap[module.Path] = subGraphName
+		}
+	}
+
+	// add node
+	cyclicNodeMap := cyclicNodeMap(cycles)
+	nodeInfoMap := m.nodeInfoMap()
+	hasStdlibNode := lo.SomeBy(lo.Values(nodeInfoMap), func(info *NodeInfo) bool { return info.IsStdlib })
+	if hasStdlibNode {
+		if err = graph.AddSubGraph(graph.Name, stdlibSubGraphName, map[string]string{
+			"label":     fmt.Sprintf(`"%s"`, stdlibGroupLabel),
+			"style":     `"dashed"`,
+			"color":     `"white"`,
+			"fontcolor": `"white"`,
+		}); err != nil {
+			return err
+		}
+	}
+	for nodeName, info := range nodeInfoMap {
+		parentGraph := "G"
+		if subGraphName, ok := moduleSubGraphNameMap[info.Module]; ok {
+			parentGraph = subGraphName
+		}
+		if info.IsStdlib {
+			parentGraph = stdlibSubGraphName
+		}
+		extraAttrs := make(map[string]string)
+		if layerIndex := m.config.LayerIndex(nodeName); layerIndex >= 0 {
+			parentGraph = m.layerSubGraphName(m.config.Layers[layerIndex].Rank)
+			extraAttrs["fillcolor"] = layerFillColors[layerIndex%len(layerFillColors)]
+		}
+		if _, ok := cyclicNodeMap[nodeName]; ok {
+			extraAttrs["color"] = `"orange"`
+			extraAttrs["penwidth"] = "3"
+		}
+		if info.IsExternal {
+			if err = graph.AddNode(parentGraph, m.toDotLangFormat(nodeName), lo.Assign(
+				nodeDefaultAttrs,
+				map[string]string{
+					"fillcolor": fmt.Sprintf(`"%s"`, externalFillColor),
+					"label":     fmt.Sprintf(`"{external: %s|pkg: %d%s}"`, info.Name, info.ContainsPackageNum, m.layerLabel(info)),
+				},
+				extraAttrs,
+			)); err != nil {
+				return err
+			}
+		} else if info.IsGrouping {
+			if graph.IsNode(nodeName) {
+				continue
+			}
+			if err = graph.AddNode(parentGraph, m.toDotLangFormat(nodeName), lo.Assign(
+				nodeDefaultAttrs,
+				map[string]string{
+					"fillcolor": "9",
+					"label":     fmt.Sprintf(`"{path: %s|pkg: %d%s%s}"`, info.DirectoryPath, info.ContainsPackageNum, m.layerLabel(info), m.stdlibLabel(info)),
+				},
+				extraAttrs,
+			)); err != nil {
+				return err
+			}
+		} else {
+			if err = graph.AddNode(parentGraph, m.toDotLangFormat(nodeName), lo.Assign(
+				nodeDefaultAttrs,
+				map[string]string{
+					"fillcolor": "10",
+					"label":     fmt.Sprintf(`"{pkg: %s|path: %s%s%s}"`, info.Name, info.DirectoryPath, m.layerLabel(info), m.stdlibLabel(info)),
+				},
+				extraAttrs,
+			)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// rank layers from top to bottom by invisible edges between them
+	var upperLayerNodeName string
+	for i := 0; i < m.config.LayerRankNum(); i++ {
+		layerNodeNames := lo.Filter(lo.Keys(nodeInfoMap), func(nodeName string, _ int) bool {
+			return m.config.LayerRank(nodeName) == i
+		})
+		if len(layerNodeNames) == 0 {
+			continue
+		}
+		sort.Strings(layerNodeNames)
+		if upperLayerNodeName != "" {
+			if err = graph.AddEdge(m.toDotLangFormat(upperLayerNodeName), m.toDotLangFormat(layerNodeNames[0]), true, map[string]string{
+				"style": `"invis"`,
+			}); err != nil {
+				return err
+			}
+		}
+		upperLayerNodeName = layerNodeNames[0]
+	}
+
+	// add edge
+	testOnlyRelationMap := m.nodeTestOnlyRelationMap()
+	for srcNodeName, relationMap := range m.nodeRelationCountMap() {
+		for dstNodeName, relationNum := range relationMap {
+			importTypes := m.nodeImportTypes(srcNodeName, dstNodeName)
+			edgeAttrs := map[string]string{
+				"color":     `"white"`,
+				"weight":    fmt.Sprintf(`"%d"`, relationNum),
+				"label":     fmt.Sprintf(`"%s"`, m.relationLabel(srcNodeName, dstNodeName, relationNum, importTypes)),
+				"fontcolor": `"white"`,
+				"decorate":  `"true"`,
+			}
+			if m.isViolation(srcNodeName, dstNodeName) {
+				edgeAttrs["color"] = `"red"`
+				edgeAttrs["tooltip"] = m.referencesTooltip(srcNodeName, dstNodeName)
+			} else {
+				if m.isCyclicRelation(cyclicNodeMap, srcNodeName, dstNodeName) {
+					edgeAttrs["color"] = `"orange"`
+				}
+				if lines := identifierKindLines(m.nodeUsageKindMap(srcNodeName, dstNodeName)); len(lines) > 0 {
+					edgeAttrs["tooltip"] = fmt.Sprintf(`"%s"`, strings.Join(lines, `\n`))
+				}
+			}
+			styles := make([]string, 0)
+			if isTestOnlyRelation(testOnlyRelationMap, srcNodeName, dstNodeName) {
+				styles = append(styles, "dashed")
+			} else if isBlankImportOnly(importTypes) {
+				styles = append(styles, "dotted")
+			}
+			if hasUnqualifiedImport(importTypes) {
+				styles = append(styles, "bold")
+			}
+			if len(styles) > 0 {
+				edgeAttrs["style"] = fmt.Sprintf(`"%s"`, strings.Join(styles, ","))
+			}
+			if err = graph.AddEdge(m.toDotLangFormat(srcNodeName), m.toDotLangFormat(dstNodeName), true, edgeAttrs); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = fmt.Fprint(m.output, graph.String()); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *Prelviz) toDotLangFormat(in string) string {
+	return fmt.Sprintf(`"%s"`, in)
+}
+
+func (m *Prelviz) referencesTooltip(srcNodeName, dstNodeName string) string {
+	lines := lo.Map(m.nodeReferences(srcNodeName, dstNodeName), func(r *Reference, _ int) string {
+		return dotEscaper.Replace(r.String())
+	})
+	return fmt.Sprintf(`"%s"`, strings.Join(lines, `\n`))
+}
+
+// dotEscaper escapes a string in a quoted attribute of dot. Backslashes are escaped as well as quotes,
+// so the backslashes of a path, such as a windows file path, are not taken as escapes.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func (m *Prelviz) layerSubGraphName(rank int) string {
+	return fmt.Sprintf("layer_%d", rank)
+}
+
+// stdlibSubGraphName is the cluster of the standard library nodes.
+const stdlibSubGraphName = "cluster_stdlib"
+
+// moduleSubGraphName returns the name of the cluster of a module. The cluster prefix makes graphviz draw it as a box.
+func (m *Prelviz) moduleSubGraphName(moduleIndex int) string {
+	return fmt.Sprintf("cluster_module_%d", moduleIndex)
+}
+
+func (m *Prelviz) layerLabel(info *NodeInfo) string {
+	if info.Layer == "" {
+		return ""
+	}
+	return fmt.Sprintf("|layer: %s", info.Layer)
+}
+
+func (m *Prelviz) stdlibLabel(info *NodeInfo) string {
+	lines := stdlibUsageLines(info)
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`|stdlib:\l%s\l`, strings.Join(lines, `\l`))
+}
@@ -0,0 +1,54 @@
+package prelviz
+
+import "testing"
+
+func TestPrelviz_referencesTooltip(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     string
+	}{
+		{
+			name:     "normal: quotes of the import are escaped",
+			filePath: "app/app.go",
+			want:     `"app/app.go:3: import \"mod/domain\"\napp/app.go:6: New"`,
+		},
+		{
+			name:     "normal: backslashes of a windows file path are escaped",
+			filePath: `app\app.go`,
+			want:     `"app\\app.go:3: import \"mod/domain\"\napp\\app.go:6: New"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Prelviz{
+				projectModuleName: "mod",
+				packageInfoMap: map[string]*PackageInfo{
+					"app": {
+						Name:          "app",
+						DirectoryPath: "app",
+						ImportUsageMap: map[string]map[string]struct{}{
+							"mod/domain": {"New": {}},
+						},
+						ImportPositionMap: map[string][]Position{
+							"mod/domain": {{FilePath: tt.filePath, Line: 3, Column: 2}},
+						},
+						UsagePositionMap: map[string]map[string][]Position{
+							"mod/domain": {"New": {{FilePath: tt.filePath, Line: 6, Column: 2, Func: "Run"}}},
+						},
+					},
+					"domain": {
+						Name:          "domain",
+						DirectoryPath: "domain",
+					},
+				},
+				config: &Config{
+					ExcludePackageMap: make(map[string]struct{}),
+				},
+			}
+			if got := m.referencesTooltip("mod/app", "mod/domain"); got != tt.want {
+				t.Errorf("Prelviz.referencesTooltip() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package prelviz
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/awalterschulze/gographviz"
+	"github.com/samber/lo"
+)
+
+// SymbolGraph is the drill-down of the relation from the src node to the dst node:
+// which functions in the files of the src node reference which symbols of the dst node.
+type SymbolGraph struct {
+	SchemaVersion int                `json:"schema_version"`
+	From          string             `json:"from"`
+	To            string             `json:"to"`
+	Callers       []*SymbolCaller    `json:"callers"`
+	Symbols       []*Symbol          `json:"symbols"`
+	References    []*SymbolReference `json:"references"`
+}
+
+// SymbolCaller is a function of the src node which references symbols of the dst node.
+// Func is empty for the references out of functions, such as package level variables.
+type SymbolCaller struct {
+	ID       string `json:"id"`
+	FilePath string `json:"file_path"`
+	Func     string `json:"func,omitempty"`
+}
+
+// Symbol is an exported identifier of a package in the dst node. Kind is empty unless the packages are loaded with type information.
+type Symbol struct {
+	ID         string `json:"id"`
+	ImportPath string `json:"import_path"`
+	Name       string `json:"name"`
+	Kind       string `json:"kind,omitempty"`
+}
+
+// SymbolReference is the references from a caller to a symbol.
+type SymbolReference struct {
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	Count     int        `json:"count"`
+	Positions []Position `json:"positions"`
+}
+
+// callerID returns the id of the caller of the position, such as "app/usecase/sample.go:Run".
+func callerID(position Position) string {
+	if position.Func == "" {
+		return position.FilePath
+	}
+	return position.FilePath + ":" + position.Func
+}
+
+// label returns the name of the function, or "(package scope)" out of functions.
+func (c *SymbolCaller) label() string {
+	if c.Func == "" {
+		return "(package scope)"
+	}
+	return c.Func
+}
+
+// label returns the name of the symbol with the kind, such as "User(type)".
+func (s *Symbol) label() string {
+	if s.Kind == "" {
+		return s.Name
+	}
+	return fmt.Sprintf("%s(%s)", s.Name, s.Kind)
+}
+
+// Edge writes the drill-down graph of the relation from the src node to the dst node in the format of dot, json or mermaid.
+func (m *Prelviz) Edge(srcNodeName, dstNodeName string) error {
+	graph, err := m.symbolGraph(srcNodeName, dstNodeName)
+	if err != nil {
+		return err
+	}
+	switch m.format {
+	case FormatDot, "":
+		return m.writeSymbolDot(graph)
+	case FormatJSON:
+		encoder := json.NewEncoder(m.output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(graph)
+	case FormatMermaid:
+		_, err = fmt.Fprint(m.output, symbolMermaid(graph))
+		return err
+	default:
+		return fmt.Errorf("unsupported edge format: %s", m.format)
+	}
+}
+
+// symbolGraph collects the references from the functions of the src node to the symbols of the dst node
+// from the selector usages. It returns an error when the src node references no symbol of the dst node.
+func (m *Prelviz) symbolGraph(srcNodeName, dstNodeName string) (*SymbolGraph, error) {
+	callerMap := make(map[string]*SymbolCaller)
+	symbolMap := make(map[string]*Symbol)
+	referenceMap := make(map[string]map[string]*SymbolReference)
+	m.walkNodeImports(srcNodeName, dstNodeName, func(importPath string, info *PackageInfo) {
+		for usage, positions := range info.UsagePositionMap[importPath] {
+			symbolID := importPath + "." + usage
+			symbolMap[symbolID] = &Symbol{
+				ID:         symbolID,
+				ImportPath: importPath,
+				Name:       usage,
+				Kind:       info.UsageKindMap[importPath][usage],
+			}
+			for _, position := range positions {
+				id := callerID(position)
+				callerMap[id] = &SymbolCaller{ID: id, FilePath: position.FilePath, Func: position.Func}
+				if _, ok := referenceMap[id]; !ok {
+					referenceMap[id] = make(map[string]*SymbolReference)
+				}
+				reference, ok := referenceMap[id][symbolID]
+				if !ok {
+					reference = &SymbolReference{From: id, To: symbolID}
+					referenceMap[id][symbolID] = reference
+				}
+				reference.Count++
+				reference.Positions = append(reference.Positions, position)
+			}
+		}
+	})
+	if len(referenceMap) == 0 {
+		return nil, fmt.Errorf("%s references no symbol of %s", srcNodeName, dstNodeName)
+	}
+
+	graph := &SymbolGraph{
+		SchemaVersion: JSONSchemaVersion,
+		From:          srcNodeName,
+		To:            dstNodeName,
+		Callers:       make([]*SymbolCaller, 0, len(callerMap)),
+		Symbols:       make([]*Symbol, 0, len(symbolMap)),
+		References:    make([]*SymbolReference, 0),
+	}
+	for _, id := range sortedKeys(callerMap) {
+		graph.Callers = append(graph.Callers, callerMap[id])
+	}
+	for _, id := range sortedKeys(symbolMap) {
+		graph.Symbols = append(graph.Symbols, symbolMap[id])
+	}
+	for _, callerID := range sortedKeys(referenceMap) {
+		for _, symbolID := range sortedKeys(referenceMap[callerID]) {
+			reference := referenceMap[callerID][symbolID]
+			sort.Slice(reference.Positions, func(i, j int) bool {
+				if reference.Positions[i].Line != reference.Positions[j].Line {
+					return reference.Positions[i].Line < reference.Positions[j].Line
+				}
+				return reference.Positions[i].Column < reference.Positions[j].Column
+			})
+			graph.References = append(graph.References, reference)
+		}
+	}
+	return graph, nil
+}
+
+// writeSymbolDot writes the symbol graph in the dot format. The callers are grouped by the file and the symbols by the package.
+func (m *Prelviz) writeSymbolDot(symbolGraph *SymbolGraph) error {
+	nodeDefaultAttrs := map[string]string{
+		"shape":       `"box"`,
+		"style":       `"solid,filled"`,
+		"fontcolor":   "6",
+		"fontsize":    "14",
+		"color":       "7",
+		"colorscheme": `"spectral11"`,
+	}
+	clusterAttrs := func(label string) map[string]string {
+		return map[string]string{
+			"label":     fmt.Sprintf(`"%s"`, label),
+			"style":     `"dashed"`,
+			"color":     `"white"`,
+			"fontcolor": `"white"`,
+		}
+	}
+
+	graphAst, _ := gographviz.ParseString(`digraph d {}`)
+	graph := gographviz.NewGraph()
+	if err := gographviz.Analyse(graphAst, graph); err != nil {
+		return err
+	}
+	graphAttrs, err := gographviz.NewAttrs(map[string]string{
+		"charset":   `"UTF-8"`,
+		"label":     fmt.Sprintf(`"%s -> %s"`, symbolGraph.From, symbolGraph.To),
+		"labelloc":  `"t"`,
+		"labeljust": `"c"`,
+		"bgcolor":   `"#343434"`,
+		"fontsize":  "18",
+		"fontcolor": `"white"`,
+		"style":     `"filled"`,
+		"rankdir":   `"LR"`,
+		"margin":    "0.5",
+	})
+	if err != nil {
+		return err
+	}
+	graph.Attrs.Extend(graphAttrs)
+
+	fileSubGraphNameMap := make(map[string]string)
+	for _, caller := range symbolGraph.Callers {
+		subGraphName, ok := fileSubGraphNameMap[caller.FilePath]
+		if !ok {
+			subGraphName = fmt.Sprintf("cluster_file_%d", len(fileSubGraphNameMap))
+			if err = graph.AddSubGraph(graph.Name, subGraphName, clusterAttrs(caller.FilePath)); err != nil {
+				return err
+			}
+			fileSubGraphNameMap[caller.FilePath] = subGraphName
+		}
+		if err = graph.AddNode(subGraphName, m.toDotLangFormat(caller.ID), lo.Assign(nodeDefaultAttrs, map[string]string{
+			"fillcolor": "10",
+			"label":     fmt.Sprintf(`"%s"`, caller.label()),
+		})); err != nil {
+			return err
+		}
+	}
+
+	packageSubGraphNameMap := make(map[string]string)
+	for _, symbol := range symbolGraph.Symbols {
+		subGraphName, ok := packageSubGraphNameMap[symbol.ImportPath]
+		if !ok {
+			subGraphName = fmt.Sprintf("cluster_package_%d", len(packageSubGraphNameMap))
+			if err = graph.AddSubGraph(graph.Name, subGraphName, clusterAttrs(symbol.ImportPath)); err != nil {
+				return err
+			}
+			packageSubGraphNameMap[symbol.ImportPath] = subGraphName
+		}
+		if err = graph.AddNode(subGraphName, m.toDotLangFormat(symbol.ID), lo.Assign(nodeDefaultAttrs, map[string]string{
+			"fillcolor": "9",
+			"label":     fmt.Sprintf(`"%s"`, symbol.label()),
+		})); err != nil {
+			return err
+		}
+	}
+
+	for _, reference := range symbolGraph.References {
+		if err = graph.AddEdge(m.toDotLangFormat(reference.From), m.toDotLangFormat(reference.To), true, map[string]string{
+			"color":     `"white"`,
+			"label":     fmt.Sprintf(`"ref:%d"`, reference.Count),
+			"fontcolor": `"white"`,
+			"tooltip": fmt.Sprintf(`"%s"`, strings.Join(lo.Map(reference.Positions, func(p Position, _ int) string {
+				return dotEscaper.Replace(p.String())
+			}), `\n`)),
+		}); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprint(m.output, graph.String())
+	return err
+}
+
+// symbolMermaid returns the symbol graph as a mermaid flowchart. The files and the packages are subgraphs,
+// which are written once for each file path and import path in order.
+func symbolMermaid(symbolGraph *SymbolGraph) string {
+	idMap := make(map[string]string)
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	b.WriteString("    classDef caller fill:#3288bd,color:#ffffbf\n")
+	b.WriteString("    classDef symbol fill:#66c2a5,color:#ffffbf\n")
+	writeNode := func(id, label, class string) {
+		idMap[id] = fmt.Sprintf("n%d", len(idMap))
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", idMap[id], label)
+		fmt.Fprintf(&b, "    class %s %s\n", idMap[id], class)
+	}
+
+	fileCallersMap := lo.GroupBy(symbolGraph.Callers, func(caller *SymbolCaller) string {
+		return caller.FilePath
+	})
+	for i, filePath := range sortedKeys(fileCallersMap) {
+		fmt.Fprintf(&b, "    subgraph f%d[\"%s\"]\n", i, filePath)
+		for _, caller := range fileCallersMap[filePath] {
+			writeNode(caller.ID, caller.label(), "caller")
+		}
+		b.WriteString("    end\n")
+	}
+	packageSymbolsMap := lo.GroupBy(symbolGraph.Symbols, func(symbol *Symbol) string {
+		return symbol.ImportPath
+	})
+	for i, importPath := range sortedKeys(packageSymbolsMap) {
+		fmt.Fprintf(&b, "    subgraph p%d[\"%s\"]\n", i, importPath)
+		for _, symbol := range packageSymbolsMap[importPath] {
+			writeNode(symbol.ID, symbol.label(), "symbol")
+		}
+		b.WriteString("    end\n")
+	}
+
+	for _, reference := range symbolGraph.References {
+		fmt.Fprintf(&b, "    %s -->|ref:%d| %s\n", idMap[reference.From], reference.Count, idMap[reference.To])
+	}
+	return b.String()
+}
@@ -0,0 +1,194 @@
+package prelviz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func edgeTestPrelviz() *Prelviz {
+	return &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"usecase": {
+				Name:          "usecase",
+				DirectoryPath: "usecase",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/domain/model":   {"User": {}},
+					"mod/domain/service": {"New": {}},
+				},
+				UsagePositionMap: map[string]map[string][]Position{
+					"mod/domain/model": {
+						"User": {
+							{FilePath: "usecase/user.go", Line: 12, Column: 9, Func: "UserUsecase.Find"},
+							{FilePath: "usecase/user.go", Line: 5, Column: 2},
+							{FilePath: "usecase/user.go", Line: 10, Column: 20, Func: "UserUsecase.Find"},
+						},
+					},
+					"mod/domain/service": {
+						"New": {{FilePath: "usecase/user.go", Line: 8, Column: 9, Func: "NewUserUsecase"}},
+					},
+				},
+				UsageKindMap: map[string]map[string]string{
+					"mod/domain/model": {"User": IdentifierKindType},
+				},
+			},
+			"domain/model":   {Name: "model", DirectoryPath: "domain/model"},
+			"domain/service": {Name: "service", DirectoryPath: "domain/service"},
+		},
+		config: &Config{
+			GroupingDirectoryPaths: []string{"domain"},
+			ExcludePackageMap:      make(map[string]struct{}),
+		},
+	}
+}
+
+func TestPrelviz_symbolGraph(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    *SymbolGraph
+		wantErr bool
+	}{
+		{
+			name: "normal: references are grouped by the function",
+			from: "mod/usecase",
+			to:   "mod/domain",
+			want: &SymbolGraph{
+				SchemaVersion: JSONSchemaVersion,
+				From:          "mod/usecase",
+				To:            "mod/domain",
+				Callers: []*SymbolCaller{
+					{ID: "usecase/user.go", FilePath: "usecase/user.go"},
+					{ID: "usecase/user.go:NewUserUsecase", FilePath: "usecase/user.go", Func: "NewUserUsecase"},
+					{ID: "usecase/user.go:UserUsecase.Find", FilePath: "usecase/user.go", Func: "UserUsecase.Find"},
+				},
+				Symbols: []*Symbol{
+					{ID: "mod/domain/model.User", ImportPath: "mod/domain/model", Name: "User", Kind: IdentifierKindType},
+					{ID: "mod/domain/service.New", ImportPath: "mod/domain/service", Name: "New"},
+				},
+				References: []*SymbolReference{
+					{
+						From:      "usecase/user.go",
+						To:        "mod/domain/model.User",
+						Count:     1,
+						Positions: []Position{{FilePath: "usecase/user.go", Line: 5, Column: 2}},
+					},
+					{
+						From:      "usecase/user.go:NewUserUsecase",
+						To:        "mod/domain/service.New",
+						Count:     1,
+						Positions: []Position{{FilePath: "usecase/user.go", Line: 8, Column: 9, Func: "NewUserUsecase"}},
+					},
+					{
+						From:  "usecase/user.go:UserUsecase.Find",
+						To:    "mod/domain/model.User",
+						Count: 2,
+						Positions: []Position{
+							{FilePath: "usecase/user.go", Line: 10, Column: 20, Func: "UserUsecase.Find"},
+							{FilePath: "usecase/user.go", Line: 12, Column: 9, Func: "UserUsecase.Find"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "abnormal: no reference",
+			from:    "mod/domain",
+			to:      "mod/usecase",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := edgeTestPrelviz().symbolGraph(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Prelviz.symbolGraph() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Prelviz.symbolGraph() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrelviz_Edge_mermaid(t *testing.T) {
+	var output bytes.Buffer
+	m := edgeTestPrelviz()
+	m.output = &output
+	m.format = FormatMermaid
+	if err := m.Edge("mod/usecase", "mod/domain"); err != nil {
+		t.Fatalf("Prelviz.Edge() error = %v", err)
+	}
+	want := `flowchart LR
+    classDef caller fill:#3288bd,color:#ffffbf
+    classDef symbol fill:#66c2a5,color:#ffffbf
+    subgraph f0["usecase/user.go"]
+    n0["(package scope)"]
+    class n0 caller
+    n1["NewUserUsecase"]
+    class n1 caller
+    n2["UserUsecase.Find"]
+    class n2 caller
+    end
+    subgraph p0["mod/domain/model"]
+    n3["User(type)"]
+    class n3 symbol
+    end
+    subgraph p1["mod/domain/service"]
+    n4["New"]
+    class n4 symbol
+    end
+    n0 -->|ref:1| n3
+    n1 -->|ref:1| n4
+    n2 -->|ref:2| n3
+`
+	if got := output.String(); got != want {
+		t.Errorf("Prelviz.Edge() = %v, want %v", got, want)
+	}
+}
+
+func Test_symbolMermaid(t *testing.T) {
+	// the callers of a file are not adjacent in the order of the ids, since "." sorts before ":".
+	symbolGraph := &SymbolGraph{
+		Callers: []*SymbolCaller{
+			{ID: "app/a.go", FilePath: "app/a.go"},
+			{ID: "app/a.go.go:Run", FilePath: "app/a.go.go", Func: "Run"},
+			{ID: "app/a.go:Run", FilePath: "app/a.go", Func: "Run"},
+		},
+		Symbols: []*Symbol{
+			{ID: "mod/domain.User", ImportPath: "mod/domain", Name: "User"},
+		},
+		References: []*SymbolReference{
+			{From: "app/a.go", To: "mod/domain.User", Count: 1},
+			{From: "app/a.go.go:Run", To: "mod/domain.User", Count: 1},
+			{From: "app/a.go:Run", To: "mod/domain.User", Count: 2},
+		},
+	}
+	want := `flowchart LR
+    classDef caller fill:#3288bd,color:#ffffbf
+    classDef symbol fill:#66c2a5,color:#ffffbf
+    subgraph f0["app/a.go"]
+    n0["(package scope)"]
+    class n0 caller
+    n1["Run"]
+    class n1 caller
+    end
+    subgraph f1["app/a.go.go"]
+    n2["Run"]
+    class n2 caller
+    end
+    subgraph p0["mod/domain"]
+    n3["User"]
+    class n3 symbol
+    end
+    n0 -->|ref:1| n3
+    n2 -->|ref:1| n3
+    n1 -->|ref:2| n3
+`
+	if got := symbolMermaid(symbolGraph); got != want {
+		t.Errorf("symbolMermaid() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package prelviz
+
+import "fmt"
+
+const (
+	// ExternalNone draws only the packages of the project.
+	ExternalNone = "none"
+	// ExternalPackage draws every imported package out of the project as a node.
+	ExternalPackage = "package"
+	// ExternalModule draws the packages of a module required by go.mod as one node named after the module.
+	// The other packages out of the project, such as the standard library, are drawn one by one.
+	ExternalModule = "module"
+)
+
+// externalFillColor is the fill color of external nodes.
+const externalFillColor = "#5e5e5e"
+
+// cgoImportPath is the pseudo package of cgo. It is drawn as an external node, since it is not of the standard library.
+const cgoImportPath = "C"
+
+// validateExternal returns an error for an unsupported external mode.
+func validateExternal(external string) error {
+	switch external {
+	case ExternalNone, ExternalPackage, ExternalModule, "":
+		return nil
+	default:
+		return fmt.Errorf("unsupported external mode: %s", external)
+	}
+}
+
+// isNodePackage reports whether the imported package is drawn as a node or in a node.
+func (m *Prelviz) isNodePackage(importPath string) bool {
+	return m.isTargetPackage(importPath) || m.isExternalPackage(importPath)
+}
+
+// isExternalPackage reports whether the imported package is out of the project and drawn as a node.
+// Packages of the standard library are drawn by the stdlib mode, or by the external mode if it is empty.
+func (m *Prelviz) isExternalPackage(importPath string) bool {
+	switch m.importKind(importPath) {
+	case ImportKindInternal:
+		return false
+	case ImportKindStdlib:
+		if m.stdlib != "" {
+			return m.stdlib == StdlibNode
+		}
+	}
+	return m.external != ExternalNone && m.external != ""
+}
+
+// externalNodeName returns the node name of the package out of the project.
+func (m *Prelviz) externalNodeName(importPath string) string {
+	if m.external == ExternalModule {
+		if modulePath, ok := m.moduleWorkspace().RequiredModulePath(importPath); ok {
+			return modulePath
+		}
+	}
+	return importPath
+}
+
+// addExternalNodeInfos adds the nodes of the packages out of the project which the project imports.
+// They are named after the import path or the module path, and ContainsPackageNum is the number of the imported packages.
+func (m *Prelviz) addExternalNodeInfos(nodeInfoMap map[string]*NodeInfo) {
+	importPathMap := make(map[string]map[string]struct{})
+	for pkgDirPath, info := range m.packageInfoMap {
+		if m.isExcludePackageWithDirPath(pkgDirPath) {
+			continue
+		}
+		for _, importUsageMap := range []map[string]map[string]struct{}{info.ImportUsageMap, info.TestImportUsageMap} {
+			for importPath := range importUsageMap {
+				if !m.isExternalPackage(importPath) || m.isExcludePackage(importPath) {
+					continue
+				}
+				nodeName := m.externalNodeName(importPath)
+				if _, ok := importPathMap[nodeName]; !ok {
+					importPathMap[nodeName] = make(map[string]struct{})
+				}
+				importPathMap[nodeName][importPath] = struct{}{}
+			}
+		}
+	}
+
+	for nodeName, pathMap := range importPathMap {
+		nodeInfoMap[nodeName] = &NodeInfo{
+			Name:               nodeName,
+			ContainsPackageNum: len(pathMap),
+			Layer:              m.layerName(nodeName),
+			IsExternal:         true,
+			IsStdlib:           m.importKind(nodeName) == ImportKindStdlib,
+		}
+	}
+}
+
+func (m *Prelviz) hasExternalNode(nodeInfoMap map[string]*NodeInfo) bool {
+	for _, info := range nodeInfoMap {
+		if info.IsExternal {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package prelviz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrelviz_external(t *testing.T) {
+	packageInfoMap := map[string]*PackageInfo{
+		"infra": {
+			Name:          "infra",
+			DirectoryPath: "infra",
+			ImportUsageMap: map[string]map[string]struct{}{
+				"database/sql":                            {"Open": {}},
+				"github.com/aws/aws-sdk-go-v2/aws":        {"String": {}},
+				"github.com/aws/aws-sdk-go-v2/service/s3": {"New": {}, "Options": {}},
+				"C": {"free": {}},
+				"github.com/aws/aws-sdk-go-v2-extension/util": {"Do": {}},
+			},
+		},
+		"app": {
+			Name:          "app",
+			DirectoryPath: "app",
+			ImportUsageMap: map[string]map[string]struct{}{
+				"mod/infra":    {"New": {}},
+				"database/sql": {"DB": {}},
+			},
+		},
+	}
+	workspace := NewModuleWorkspace("mod")
+	workspace.RequiredModulePaths = []string{"github.com/aws/aws-sdk-go-v2", "github.com/aws/aws-sdk-go-v2-extension"}
+	ngRelationMap := map[string]map[string]struct{}{
+		"mod/app": {"database/...": {}},
+	}
+
+	tests := []struct {
+		name               string
+		external           string
+		excludePackageMap  map[string]struct{}
+		wantRelationMap    map[string]map[string]int
+		wantExternalPkgNum map[string]int
+	}{
+		{
+			name:     "normal: external packages are not drawn",
+			external: ExternalNone,
+			wantRelationMap: map[string]map[string]int{
+				"mod/app": {"mod/infra": 1},
+			},
+			wantExternalPkgNum: map[string]int{},
+		},
+		{
+			name:     "normal: every external package is a node",
+			external: ExternalPackage,
+			wantRelationMap: map[string]map[string]int{
+				"mod/app": {"mod/infra": 1, "database/sql": 1},
+				"mod/infra": {
+					"C":                                1,
+					"database/sql":                     1,
+					"github.com/aws/aws-sdk-go-v2/aws": 1,
+					"github.com/aws/aws-sdk-go-v2/service/s3":     2,
+					"github.com/aws/aws-sdk-go-v2-extension/util": 1,
+				},
+			},
+			wantExternalPkgNum: map[string]int{
+				"C":                                1,
+				"database/sql":                     1,
+				"github.com/aws/aws-sdk-go-v2/aws": 1,
+				"github.com/aws/aws-sdk-go-v2/service/s3":     1,
+				"github.com/aws/aws-sdk-go-v2-extension/util": 1,
+			},
+		},
+		{
+			name:     "normal: packages of a required module are collapsed",
+			external: ExternalModule,
+			wantRelationMap: map[string]map[string]int{
+				"mod/app": {"mod/infra": 1, "database/sql": 1},
+				"mod/infra": {
+					"C":                                      1,
+					"database/sql":                           1,
+					"github.com/aws/aws-sdk-go-v2":           3,
+					"github.com/aws/aws-sdk-go-v2-extension": 1,
+				},
+			},
+			wantExternalPkgNum: map[string]int{
+				"C":                                      1,
+				"database/sql":                           1,
+				"github.com/aws/aws-sdk-go-v2":           2,
+				"github.com/aws/aws-sdk-go-v2-extension": 1,
+			},
+		},
+		{
+			name:              "normal: a collapsed module is excluded by the module path",
+			external:          ExternalModule,
+			excludePackageMap: map[string]struct{}{"github.com/aws/aws-sdk-go-v2": {}},
+			wantRelationMap: map[string]map[string]int{
+				"mod/app": {"mod/infra": 1, "database/sql": 1},
+				"mod/infra": {
+					"C":                                      1,
+					"database/sql":                           1,
+					"github.com/aws/aws-sdk-go-v2-extension": 1,
+				},
+			},
+			wantExternalPkgNum: map[string]int{
+				"C":                                      1,
+				"database/sql":                           1,
+				"github.com/aws/aws-sdk-go-v2-extension": 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			excludePackageMap := tt.excludePackageMap
+			if excludePackageMap == nil {
+				excludePackageMap = make(map[string]struct{})
+			}
+			m := &Prelviz{
+				projectModuleName: "mod",
+				workspace:         workspace,
+				packageInfoMap:    packageInfoMap,
+				config: &Config{
+					NgRelationMap:     ngRelationMap,
+					ExcludePackageMap: excludePackageMap,
+				},
+				external: tt.external,
+			}
+			if got := m.nodeRelationCountMap(); !reflect.DeepEqual(got, tt.wantRelationMap) {
+				t.Errorf("Prelviz.nodeRelationCountMap() = %v, want %v", got, tt.wantRelationMap)
+			}
+			externalPkgNum := make(map[string]int)
+			for nodeName, info := range m.nodeInfoMap() {
+				if info.IsExternal {
+					externalPkgNum[nodeName] = info.ContainsPackageNum
+				}
+			}
+			if !reflect.DeepEqual(externalPkgNum, tt.wantExternalPkgNum) {
+				t.Errorf("Prelviz.nodeInfoMap() external nodes = %v, want %v", externalPkgNum, tt.wantExternalPkgNum)
+			}
+			wantViolation := tt.external != ExternalNone
+			if got := len(m.violations()) > 0; got != wantViolation {
+				t.Errorf("Prelviz.violations() found = %v, want %v", got, wantViolation)
+			}
+		})
+	}
+}
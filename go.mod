@@ -1,10 +1,16 @@
 module github.com/kazdevl/prelviz
 
-go 1.21
+go 1.22.0
 
 require (
 	github.com/awalterschulze/gographviz v2.0.3+incompatible
 	github.com/samber/lo v1.39.0
+	golang.org/x/image v0.18.0
+	golang.org/x/mod v0.21.0
+	golang.org/x/tools v0.26.0
 )
 
-require golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17 // indirect
+require (
+	golang.org/x/exp v0.0.0-20220303212507-bbda1eaf7a17 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+)
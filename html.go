@@ -0,0 +1,81 @@
+package prelviz
+
+import (
+	_ "embed"
+	"html/template"
+	"sort"
+	"strings"
+)
+
+//go:embed template/report.html
+var reportTemplateText string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportTemplateText))
+
+// htmlReport is the data of the html report template.
+type htmlReport struct {
+	Title string
+	SVG   template.HTML
+	Data  *htmlReportData
+	// LiveReload reloads the page when the server pushes an update.
+	LiveReload bool
+}
+
+// htmlReportData is embedded in the html report as json and shown in the side panel.
+type htmlReportData struct {
+	Graph   *JSONGraph          `json:"graph"`
+	FileMap map[string][]string `json:"files"`
+}
+
+// writeHTML writes a self-contained html page which has the svg image of the graph,
+// pan and zoom, the package search, highlighting of in and out edges and the side panel.
+func (m *Prelviz) writeHTML(cycles [][]string) error {
+	report, err := m.htmlReport(cycles)
+	if err != nil {
+		return err
+	}
+	return reportTemplate.Execute(m.output, report)
+}
+
+func (m *Prelviz) htmlReport(cycles [][]string) (*htmlReport, error) {
+	s, err := m.scene(cycles)
+	if err != nil {
+		return nil, err
+	}
+	var svg strings.Builder
+	if err = s.writeSVG(&svg); err != nil {
+		return nil, err
+	}
+	return &htmlReport{
+		Title: m.title(),
+		SVG:   template.HTML(svg.String()),
+		Data: &htmlReportData{
+			Graph:   m.jsonGraph(cycles),
+			FileMap: m.nodeFileMap(),
+		},
+	}, nil
+}
+
+// title returns the module of the project, or the modules of a workspace of multiple modules.
+func (m *Prelviz) title() string {
+	if m.projectModuleName != "" {
+		return m.projectModuleName
+	}
+	return strings.Join(m.moduleWorkspace().ModulePaths(), ", ")
+}
+
+// nodeFileMap returns the sorted go files of the packages in each node.
+func (m *Prelviz) nodeFileMap() map[string][]string {
+	fileMap := make(map[string][]string)
+	for pkgDirPath, info := range m.packageInfoMap {
+		if m.isExcludePackageWithDirPath(pkgDirPath) {
+			continue
+		}
+		nodeName := m.nodeName(pkgDirPath)
+		fileMap[nodeName] = append(fileMap[nodeName], info.FilePaths...)
+	}
+	for _, filePaths := range fileMap {
+		sort.Strings(filePaths)
+	}
+	return fileMap
+}
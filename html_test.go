@@ -0,0 +1,69 @@
+package prelviz
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrelviz_writeHTML(t *testing.T) {
+	output := new(bytes.Buffer)
+	m := newImageTestPrelviz()
+	m.output = output
+	if err := m.writeHTML(nil); err != nil {
+		t.Fatalf("Prelviz.writeHTML() error = %v", err)
+	}
+	got := output.String()
+	for _, want := range []string{
+		`<title>mod - package relation</title>`,
+		`<g class="node" data-node="mod/sample/src">`,
+		`<g class="edge" data-from="mod/sample/src" data-to="mod/sample/dst">`,
+		`"identifiers":{"mod/sample/dst":["Sample1"]}`,
+		`"violated_rule":"ng_relation"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Prelviz.writeHTML() do not contain %s", want)
+		}
+	}
+}
+
+func TestPrelviz_nodeFileMap(t *testing.T) {
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"sample/src": {
+				Name:          "src",
+				DirectoryPath: "sample/src",
+				FilePaths:     []string{"sample/src/b.go", "sample/src/a.go"},
+			},
+			"sample/grouping/dst1": {
+				Name:          "dst1",
+				DirectoryPath: "sample/grouping/dst1",
+				FilePaths:     []string{"sample/grouping/dst1/dst1.go"},
+			},
+			"sample/grouping/dst2": {
+				Name:          "dst2",
+				DirectoryPath: "sample/grouping/dst2",
+				FilePaths:     []string{"sample/grouping/dst2/dst2.go"},
+			},
+			"sample/exclude": {
+				Name:          "exclude",
+				DirectoryPath: "sample/exclude",
+				FilePaths:     []string{"sample/exclude/exclude.go"},
+			},
+		},
+		config: &Config{
+			NgRelationMap:          make(map[string]map[string]struct{}),
+			GroupingDirectoryPaths: []string{"sample/grouping"},
+			ExcludePackageMap:      map[string]struct{}{"mod/sample/exclude": {}},
+		},
+	}
+	want := map[string][]string{
+		"mod/sample/src":      {"sample/src/a.go", "sample/src/b.go"},
+		"mod/sample/grouping": {"sample/grouping/dst1/dst1.go", "sample/grouping/dst2/dst2.go"},
+	}
+	if got := m.nodeFileMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Prelviz.nodeFileMap() = %v, want %v", got, want)
+	}
+}
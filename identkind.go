@@ -0,0 +1,93 @@
+package prelviz
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+const (
+	// IdentifierKindType is a defined type or an alias except interfaces.
+	IdentifierKindType = "type"
+	// IdentifierKindInterface is a type whose underlying type is an interface.
+	IdentifierKindInterface = "interface"
+	// IdentifierKindFunc is a function.
+	IdentifierKindFunc = "func"
+	// IdentifierKindConst is a constant.
+	IdentifierKindConst = "const"
+	// IdentifierKindVar is a package level variable.
+	IdentifierKindVar = "var"
+)
+
+// identifierKinds is the order of the kinds in labels.
+var identifierKinds = []string{IdentifierKindType, IdentifierKindInterface, IdentifierKindFunc, IdentifierKindConst, IdentifierKindVar}
+
+// identifierKindOf returns the kind of the object which an identifier of an imported package refers to.
+// It returns "" for an object which is not classified.
+func identifierKindOf(obj types.Object) string {
+	switch x := obj.(type) {
+	case *types.TypeName:
+		if types.IsInterface(x.Type()) {
+			return IdentifierKindInterface
+		}
+		return IdentifierKindType
+	case *types.Func:
+		return IdentifierKindFunc
+	case *types.Const:
+		return IdentifierKindConst
+	case *types.Var:
+		return IdentifierKindVar
+	default:
+		return ""
+	}
+}
+
+// nodeUsageKindMap returns the kind of each identifier which packages in the src node use from packages in the dst node.
+// It is empty unless the packages are loaded with type information.
+func (m *Prelviz) nodeUsageKindMap(srcNodeName, dstNodeName string) map[string]map[string]string {
+	usageKindMap := make(map[string]map[string]string)
+	m.walkNodeImports(srcNodeName, dstNodeName, func(importPath string, info *PackageInfo) {
+		for usage, kind := range info.UsageKindMap[importPath] {
+			if _, ok := usageKindMap[importPath]; !ok {
+				usageKindMap[importPath] = make(map[string]string)
+			}
+			usageKindMap[importPath][usage] = kind
+		}
+	})
+	return usageKindMap
+}
+
+// identifierKindCounts returns the number of the identifiers of each kind.
+func identifierKindCounts(usageKindMap map[string]map[string]string) map[string]int {
+	counts := make(map[string]int)
+	for _, kindMap := range usageKindMap {
+		for _, kind := range kindMap {
+			counts[kind]++
+		}
+	}
+	return counts
+}
+
+// identifierKindsLabel returns the breakdown of the identifiers by kind, such as "types:3 funcs:5".
+func identifierKindsLabel(counts map[string]int) string {
+	parts := make([]string, 0, len(identifierKinds))
+	for _, kind := range identifierKinds {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%ss:%d", kind, counts[kind]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// identifierKindLines returns the identifiers with their kinds for the tooltip of an edge, such as "mod/domain: User(type), Repository(interface)".
+func identifierKindLines(usageKindMap map[string]map[string]string) []string {
+	lines := make([]string, 0, len(usageKindMap))
+	for _, importPath := range sortedKeys(usageKindMap) {
+		usages := make([]string, 0, len(usageKindMap[importPath]))
+		for _, usage := range sortedKeys(usageKindMap[importPath]) {
+			usages = append(usages, fmt.Sprintf("%s(%s)", usage, usageKindMap[importPath][usage]))
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", importPath, strings.Join(usages, ", ")))
+	}
+	return lines
+}
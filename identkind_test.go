@@ -0,0 +1,118 @@
+package prelviz
+
+import (
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+func Test_identifierKindOf(t *testing.T) {
+	pkg := types.NewPackage("mod/domain", "domain")
+	tests := []struct {
+		name string
+		obj  types.Object
+		want string
+	}{
+		{
+			name: "normal: type",
+			obj:  types.NewTypeName(token.NoPos, pkg, "User", types.NewStruct(nil, nil)),
+			want: IdentifierKindType,
+		},
+		{
+			name: "normal: interface",
+			obj:  types.NewTypeName(token.NoPos, pkg, "Repository", types.NewInterfaceType(nil, nil)),
+			want: IdentifierKindInterface,
+		},
+		{
+			name: "normal: func",
+			obj:  types.NewFunc(token.NoPos, pkg, "NewUser", types.NewSignatureType(nil, nil, nil, nil, nil, false)),
+			want: IdentifierKindFunc,
+		},
+		{
+			name: "normal: const",
+			obj:  types.NewConst(token.NoPos, pkg, "MaxAge", types.Typ[types.Int], constant.MakeInt64(100)),
+			want: IdentifierKindConst,
+		},
+		{
+			name: "normal: var",
+			obj:  types.NewVar(token.NoPos, pkg, "ErrNotFound", types.Universe.Lookup("error").Type()),
+			want: IdentifierKindVar,
+		},
+		{
+			name: "normal: not classified",
+			obj:  nil,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := identifierKindOf(tt.obj); got != tt.want {
+				t.Errorf("identifierKindOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrelviz_relationLabel(t *testing.T) {
+	packageInfoMap := map[string]*PackageInfo{
+		"app": {
+			Name:          "app",
+			DirectoryPath: "app",
+			ImportUsageMap: map[string]map[string]struct{}{
+				"mod/domain": {"User": {}, "Repository": {}, "NewUser": {}},
+			},
+			UsageKindMap: map[string]map[string]string{
+				"mod/domain": {"User": IdentifierKindType, "Repository": IdentifierKindInterface, "NewUser": IdentifierKindFunc},
+			},
+		},
+		"domain": {Name: "domain", DirectoryPath: "domain"},
+	}
+	tests := []struct {
+		name           string
+		packageInfoMap map[string]*PackageInfo
+		importTypes    []string
+		want           string
+		wantLines      []string
+	}{
+		{
+			name:           "normal: breakdown by kind",
+			packageInfoMap: packageInfoMap,
+			importTypes:    []string{ImportTypeNormal},
+			want:           "dep:3 types:1 interfaces:1 funcs:1",
+			wantLines:      []string{"mod/domain: NewUser(func), Repository(interface), User(type)"},
+		},
+		{
+			name: "normal: kinds are unknown without type information",
+			packageInfoMap: map[string]*PackageInfo{
+				"app": {
+					Name:          "app",
+					DirectoryPath: "app",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"mod/domain": {"User": {}, "Repository": {}, "NewUser": {}},
+					},
+				},
+				"domain": {Name: "domain", DirectoryPath: "domain"},
+			},
+			importTypes: []string{ImportTypeDot, ImportTypeNormal},
+			want:        "dep:3 (dot)",
+			wantLines:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Prelviz{
+				projectModuleName: "mod",
+				packageInfoMap:    tt.packageInfoMap,
+				config:            &Config{ExcludePackageMap: make(map[string]struct{})},
+			}
+			if got := m.relationLabel("mod/app", "mod/domain", 3, tt.importTypes); got != tt.want {
+				t.Errorf("Prelviz.relationLabel() = %v, want %v", got, tt.want)
+			}
+			if got := identifierKindLines(m.nodeUsageKindMap("mod/app", "mod/domain")); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("identifierKindLines() = %v, want %v", got, tt.wantLines)
+			}
+		})
+	}
+}
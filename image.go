@@ -0,0 +1,401 @@
+package prelviz
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/samber/lo"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// spectral11Colors are the colors of the spectral11 color scheme which the dot format uses.
+var spectral11Colors = map[string]string{
+	"1":  "#9e0142",
+	"2":  "#d53e4f",
+	"3":  "#f46d43",
+	"4":  "#fdae61",
+	"5":  "#fee08b",
+	"6":  "#ffffbf",
+	"7":  "#e6f598",
+	"8":  "#abdda4",
+	"9":  "#66c2a5",
+	"10": "#3288bd",
+	"11": "#5e4fa2",
+}
+
+const (
+	imageBackgroundColor = "#343434"
+	imageWhiteColor      = "#ffffff"
+	imageRedColor        = "#ff0000"
+	imageOrangeColor     = "#ffa500"
+	imageTitleHeight     = 40.0
+	imageCharWidth       = 7.0
+	imageLineHeight      = 16.0
+	imagePadding         = 8.0
+	imageArrowLength     = 10.0
+	imageArrowWidth      = 5.0
+	imageDashLength      = 6.0
+	imageDashGap         = 4.0
+)
+
+// scene is the package relation graph laid out for drawing images.
+type scene struct {
+	Title  string
+	Width  float64
+	Height float64
+	Nodes  []*sceneNode
+	Edges  []*sceneEdge
+}
+
+type sceneNode struct {
+	*layoutNode
+	Lines       []string
+	Fill        string
+	Stroke      string
+	StrokeWidth float64
+}
+
+type sceneEdge struct {
+	*layoutEdge
+	Color   string
+	Label   string
+	Tooltip string
+	// Dashed is true for a relation only from tests or only by blank imports.
+	Dashed bool
+	// Bold is true for a relation by dot or cgo imports.
+	Bold bool
+}
+
+// strokeWidth returns the width of the edge line.
+func (e *sceneEdge) strokeWidth() float64 {
+	if e.Bold {
+		return 3
+	}
+	return 1.5
+}
+
+func (m *Prelviz) scene(cycles [][]string) (*scene, error) {
+	cyclicNodeMap := cyclicNodeMap(cycles)
+	nodeInfoMap := m.nodeInfoMap()
+
+	nodes := make([]*sceneNode, 0, len(nodeInfoMap))
+	layoutNodes := make([]*layoutNode, 0, len(nodeInfoMap))
+	for _, nodeName := range sortedKeys(nodeInfoMap) {
+		info := nodeInfoMap[nodeName]
+		n := &sceneNode{
+			Fill:        spectral11Colors["10"],
+			Stroke:      spectral11Colors["7"],
+			StrokeWidth: 1,
+		}
+		if info.IsExternal {
+			n.Fill = externalFillColor
+			n.Lines = []string{fmt.Sprintf("external: %s", info.Name), fmt.Sprintf("pkg: %d", info.ContainsPackageNum)}
+		} else if info.IsGrouping {
+			n.Fill = spectral11Colors["9"]
+			n.Lines = []string{fmt.Sprintf("path: %s", info.DirectoryPath), fmt.Sprintf("pkg: %d", info.ContainsPackageNum)}
+		} else {
+			n.Lines = []string{fmt.Sprintf("pkg: %s", info.Name), fmt.Sprintf("path: %s", info.DirectoryPath)}
+		}
+		if layerIndex := m.config.LayerIndex(nodeName); layerIndex >= 0 {
+			n.Fill = spectral11Colors[layerFillColors[layerIndex%len(layerFillColors)]]
+			n.Lines = append(n.Lines, fmt.Sprintf("layer: %s", info.Layer))
+		}
+		if info.Module != "" {
+			n.Lines = append(n.Lines, fmt.Sprintf("module: %s", info.Module))
+		}
+		if lines := stdlibUsageLines(info); len(lines) > 0 {
+			n.Lines = append(append(n.Lines, "stdlib:"), lines...)
+		}
+		if _, ok := cyclicNodeMap[nodeName]; ok {
+			n.Stroke = imageOrangeColor
+			n.StrokeWidth = 3
+		}
+		width := 0.0
+		for _, line := range n.Lines {
+			width = math.Max(width, float64(len(line))*imageCharWidth)
+		}
+		n.layoutNode = &layoutNode{
+			ID:     nodeName,
+			Width:  width + 2*imagePadding,
+			Height: float64(len(n.Lines))*imageLineHeight + imagePadding,
+		}
+		nodes = append(nodes, n)
+		layoutNodes = append(layoutNodes, n.layoutNode)
+	}
+
+	edges := make([]*sceneEdge, 0)
+	layoutEdges := make([]*layoutEdge, 0)
+	relationCountMap := m.nodeRelationCountMap()
+	testOnlyRelationMap := m.nodeTestOnlyRelationMap()
+	for _, srcNodeName := range sortedKeys(relationCountMap) {
+		for _, dstNodeName := range sortedKeys(relationCountMap[srcNodeName]) {
+			importTypes := m.nodeImportTypes(srcNodeName, dstNodeName)
+			e := &sceneEdge{
+				layoutEdge: &layoutEdge{From: srcNodeName, To: dstNodeName},
+				Color:      imageWhiteColor,
+				Label:      m.relationLabel(srcNodeName, dstNodeName, relationCountMap[srcNodeName][dstNodeName], importTypes),
+				Dashed:     isTestOnlyRelation(testOnlyRelationMap, srcNodeName, dstNodeName) || isBlankImportOnly(importTypes),
+				Bold:       hasUnqualifiedImport(importTypes),
+			}
+			if m.isViolation(srcNodeName, dstNodeName) {
+				e.Color = imageRedColor
+				e.Tooltip = strings.Join(lo.Map(m.nodeReferences(srcNodeName, dstNodeName), func(r *Reference, _ int) string {
+					return r.String()
+				}), "\n")
+			} else {
+				e.Tooltip = strings.Join(identifierKindLines(m.nodeUsageKindMap(srcNodeName, dstNodeName)), "\n")
+				if m.isCyclicRelation(cyclicNodeMap, srcNodeName, dstNodeName) {
+					e.Color = imageOrangeColor
+				}
+			}
+			edges = append(edges, e)
+			layoutEdges = append(layoutEdges, e.layoutEdge)
+		}
+	}
+
+	// rank layers from top to bottom by invisible edges between them as well as the dot format
+	var upperLayerNodeName string
+	for i := 0; i < m.config.LayerRankNum(); i++ {
+		for _, n := range layoutNodes {
+			if m.config.LayerRank(n.ID) != i {
+				continue
+			}
+			if upperLayerNodeName != "" {
+				layoutEdges = append(layoutEdges, &layoutEdge{From: upperLayerNodeName, To: n.ID, Invisible: true})
+			}
+			upperLayerNodeName = n.ID
+			break
+		}
+	}
+
+	width, height, err := layoutGraph(m.dotLayout, layoutNodes, layoutEdges)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range layoutNodes {
+		n.Y += imageTitleHeight
+	}
+	for _, e := range layoutEdges {
+		for i := range e.Points {
+			e.Points[i].Y += imageTitleHeight
+		}
+	}
+	width = math.Max(width, float64(len("package relation"))*imageCharWidth*2)
+	return &scene{
+		Title:  "package relation",
+		Width:  width,
+		Height: height + imageTitleHeight,
+		Nodes:  nodes,
+		Edges:  edges,
+	}, nil
+}
+
+func (m *Prelviz) writeSVG(cycles [][]string) error {
+	s, err := m.scene(cycles)
+	if err != nil {
+		return err
+	}
+	return s.writeSVG(m.output)
+}
+
+func (m *Prelviz) writePNG(cycles [][]string) error {
+	s, err := m.scene(cycles)
+	if err != nil {
+		return err
+	}
+	return png.Encode(m.output, s.image())
+}
+
+func (s *scene) writeSVG(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="monospace" font-size="12">`+"\n", s.Width, s.Height, s.Width, s.Height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", imageBackgroundColor)
+	fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="white" font-size="18" text-anchor="middle">%s</text>`+"\n", s.Width/2, imageTitleHeight/2+6, html.EscapeString(s.Title))
+
+	for _, e := range s.Edges {
+		if len(e.Points) < 2 {
+			continue
+		}
+		fmt.Fprintf(&b, `<g class="edge" data-from="%s" data-to="%s">`, html.EscapeString(e.From), html.EscapeString(e.To))
+		if e.Tooltip != "" {
+			fmt.Fprintf(&b, `<title>%s</title>`, html.EscapeString(e.Tooltip))
+		}
+		line, arrow := e.arrow()
+		points := lo.Map(line, func(p point, _ int) string {
+			return fmt.Sprintf("%.1f,%.1f", p.X, p.Y)
+		})
+		fmt.Fprintf(&b, `<polyline class="hit" points="%s" fill="none" stroke="transparent" stroke-width="8"/>`, strings.Join(points, " "))
+		dashArray := ""
+		if e.Dashed {
+			dashArray = fmt.Sprintf(` stroke-dasharray="%.0f,%.0f"`, imageDashLength, imageDashGap)
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%.1f"%s/>`, strings.Join(points, " "), e.Color, e.strokeWidth(), dashArray)
+		fmt.Fprintf(&b, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s"/>`, arrow[0].X, arrow[0].Y, arrow[1].X, arrow[1].Y, arrow[2].X, arrow[2].Y, e.Color)
+		labelPoint := e.labelPoint()
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="white">%s</text>`, labelPoint.X, labelPoint.Y, html.EscapeString(e.Label))
+		b.WriteString("</g>\n")
+	}
+
+	for _, n := range s.Nodes {
+		left, top := n.X-n.Width/2, n.Y-n.Height/2
+		fmt.Fprintf(&b, `<g class="node" data-node="%s"><title>%s</title>`, html.EscapeString(n.ID), html.EscapeString(n.ID))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="%s" stroke-width="%.0f"/>`, left, top, n.Width, n.Height, n.Fill, n.Stroke, n.StrokeWidth)
+		for i, line := range n.Lines {
+			if i > 0 {
+				y := top + imagePadding/2 + float64(i)*imageLineHeight
+				fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`, left, y, left+n.Width, y, n.Stroke)
+			}
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="middle">%s</text>`, n.X, top+imagePadding/2+float64(i+1)*imageLineHeight-4, spectral11Colors["6"], html.EscapeString(line))
+		}
+		b.WriteString("</g>\n")
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (s *scene) image() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(s.Width)), int(math.Ceil(s.Height))))
+	fillRect(img, 0, 0, s.Width, s.Height, hexColor(imageBackgroundColor))
+	drawText(img, s.Width/2-float64(len(s.Title))*imageCharWidth/2, imageTitleHeight/2+4, s.Title, hexColor(imageWhiteColor))
+
+	for _, e := range s.Edges {
+		if len(e.Points) < 2 {
+			continue
+		}
+		c := hexColor(e.Color)
+		line, arrow := e.arrow()
+		for i := 0; i+1 < len(line); i++ {
+			if e.Dashed {
+				drawDashedLine(img, line[i], line[i+1], e.strokeWidth(), c)
+			} else {
+				drawLine(img, line[i], line[i+1], e.strokeWidth(), c)
+			}
+		}
+		fillTriangle(img, arrow, c)
+		labelPoint := e.labelPoint()
+		drawText(img, labelPoint.X, labelPoint.Y, e.Label, hexColor(imageWhiteColor))
+	}
+
+	for _, n := range s.Nodes {
+		left, top := n.X-n.Width/2, n.Y-n.Height/2
+		stroke := hexColor(n.Stroke)
+		fillRect(img, left, top, n.Width, n.Height, hexColor(n.Fill))
+		for i, line := range n.Lines {
+			if i > 0 {
+				y := top + imagePadding/2 + float64(i)*imageLineHeight
+				drawLine(img, point{X: left, Y: y}, point{X: left + n.Width, Y: y}, 1, stroke)
+			}
+			drawText(img, n.X-float64(len(line))*imageCharWidth/2, top+imagePadding/2+float64(i+1)*imageLineHeight-4, line, hexColor(spectral11Colors["6"]))
+		}
+		corners := []point{{X: left, Y: top}, {X: left + n.Width, Y: top}, {X: left + n.Width, Y: top + n.Height}, {X: left, Y: top + n.Height}}
+		for i := range corners {
+			drawLine(img, corners[i], corners[(i+1)%len(corners)], n.StrokeWidth, stroke)
+		}
+	}
+	return img
+}
+
+// arrow returns the polyline shortened by the arrow head and the triangle of the arrow head.
+func (e *sceneEdge) arrow() ([]point, [3]point) {
+	line := append([]point(nil), e.Points...)
+	tip, prev := line[len(line)-1], line[len(line)-2]
+	dx, dy := tip.X-prev.X, tip.Y-prev.Y
+	length := math.Max(math.Hypot(dx, dy), 0.01)
+	ux, uy := dx/length, dy/length
+	base := point{X: tip.X - ux*imageArrowLength, Y: tip.Y - uy*imageArrowLength}
+	line[len(line)-1] = base
+	return line, [3]point{
+		tip,
+		{X: base.X - uy*imageArrowWidth, Y: base.Y + ux*imageArrowWidth},
+		{X: base.X + uy*imageArrowWidth, Y: base.Y - ux*imageArrowWidth},
+	}
+}
+
+// labelPoint returns the middle of the polyline.
+func (e *sceneEdge) labelPoint() point {
+	i := (len(e.Points) - 1) / 2
+	a, b := e.Points[i], e.Points[i+1]
+	return point{X: (a.X+b.X)/2 + 4, Y: (a.Y + b.Y) / 2}
+}
+
+func hexColor(hex string) color.RGBA {
+	var r, g, b uint8
+	_, _ = fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b)
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+func fillRect(img *image.RGBA, left, top, width, height float64, c color.RGBA) {
+	for y := int(top); y < int(top+height); y++ {
+		for x := int(left); x < int(left+width); x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func drawLine(img *image.RGBA, from, to point, width float64, c color.RGBA) {
+	length := math.Hypot(to.X-from.X, to.Y-from.Y)
+	steps := int(math.Ceil(length*2)) + 1
+	half := width / 2
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x, y := from.X+(to.X-from.X)*t, from.Y+(to.Y-from.Y)*t
+		for py := int(math.Floor(y - half + 0.5)); py <= int(math.Floor(y+half-0.5)); py++ {
+			for px := int(math.Floor(x - half + 0.5)); px <= int(math.Floor(x+half-0.5)); px++ {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+func drawDashedLine(img *image.RGBA, from, to point, width float64, c color.RGBA) {
+	length := math.Hypot(to.X-from.X, to.Y-from.Y)
+	if length == 0 {
+		return
+	}
+	dx, dy := (to.X-from.X)/length, (to.Y-from.Y)/length
+	for start := 0.0; start < length; start += imageDashLength + imageDashGap {
+		end := math.Min(start+imageDashLength, length)
+		drawLine(img, point{X: from.X + dx*start, Y: from.Y + dy*start}, point{X: from.X + dx*end, Y: from.Y + dy*end}, width, c)
+	}
+}
+
+func fillTriangle(img *image.RGBA, triangle [3]point, c color.RGBA) {
+	minX := math.Min(triangle[0].X, math.Min(triangle[1].X, triangle[2].X))
+	maxX := math.Max(triangle[0].X, math.Max(triangle[1].X, triangle[2].X))
+	minY := math.Min(triangle[0].Y, math.Min(triangle[1].Y, triangle[2].Y))
+	maxY := math.Max(triangle[0].Y, math.Max(triangle[1].Y, triangle[2].Y))
+	side := func(a, b, p point) float64 {
+		return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+	}
+	for y := int(math.Floor(minY)); y <= int(math.Ceil(maxY)); y++ {
+		for x := int(math.Floor(minX)); x <= int(math.Ceil(maxX)); x++ {
+			p := point{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+			d1, d2, d3 := side(triangle[0], triangle[1], p), side(triangle[1], triangle[2], p), side(triangle[2], triangle[0], p)
+			hasNegative := d1 < 0 || d2 < 0 || d3 < 0
+			hasPositive := d1 > 0 || d2 > 0 || d3 > 0
+			if !(hasNegative && hasPositive) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+func drawText(img *image.RGBA, x, y float64, text string, c color.RGBA) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(int(x), int(y)),
+	}
+	d.DrawString(text)
+}
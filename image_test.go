@@ -0,0 +1,80 @@
+package prelviz
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func newImageTestPrelviz() *Prelviz {
+	return &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"sample/src": {
+				Name:          "src",
+				DirectoryPath: "sample/src",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/sample/dst": {"Sample1": {}},
+				},
+			},
+			"sample/dst": {
+				Name:           "dst",
+				DirectoryPath:  "sample/dst",
+				ImportUsageMap: map[string]map[string]struct{}{},
+			},
+		},
+		config: &Config{
+			NgRelationMap: map[string]map[string]struct{}{
+				"mod/sample/src": {"mod/sample/dst": {}},
+			},
+			GroupingDirectoryPaths: make([]string, 0),
+			ExcludePackageMap:      make(map[string]struct{}),
+		},
+		dotLayout: "dot",
+	}
+}
+
+func TestPrelviz_writeSVG(t *testing.T) {
+	output := new(bytes.Buffer)
+	m := newImageTestPrelviz()
+	m.output = output
+	if err := m.writeSVG(nil); err != nil {
+		t.Fatalf("Prelviz.writeSVG() error = %v", err)
+	}
+	got := output.String()
+	for _, want := range []string{
+		`<g class="node" data-node="mod/sample/src">`,
+		`<g class="node" data-node="mod/sample/dst">`,
+		`<g class="edge" data-from="mod/sample/src" data-to="mod/sample/dst">`,
+		`stroke="#ff0000"`,
+		`>dep:1</text>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Prelviz.writeSVG() do not contain %s", want)
+		}
+	}
+}
+
+func TestPrelviz_writePNG(t *testing.T) {
+	output := new(bytes.Buffer)
+	m := newImageTestPrelviz()
+	m.output = output
+	if err := m.writePNG(nil); err != nil {
+		t.Fatalf("Prelviz.writePNG() error = %v", err)
+	}
+	img, err := png.Decode(output)
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if got := color.RGBAModel.Convert(img.At(0, 0)); got != hexColor(imageBackgroundColor) {
+		t.Errorf("Prelviz.writePNG() background = %v, want %v", got, hexColor(imageBackgroundColor))
+	}
+}
+
+func Test_hexColor(t *testing.T) {
+	if got, want := hexColor("#3288bd"), (color.RGBA{R: 0x32, G: 0x88, B: 0xbd, A: 0xff}); got != want {
+		t.Errorf("hexColor() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package prelviz
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+)
+
+const (
+	// ImportTypeNormal is an import whose identifiers are used by the package name or the alias.
+	ImportTypeNormal = "normal"
+	// ImportTypeBlank is an import for side effects. ex) import _ "github.com/lib/pq"
+	ImportTypeBlank = "blank"
+	// ImportTypeDot is an import whose identifiers are used without the package name. ex) import . "fmt"
+	ImportTypeDot = "dot"
+	// ImportTypeCgo is the import of the pseudo package "C".
+	ImportTypeCgo = "cgo"
+)
+
+// importTypeOf returns the type of the import spec.
+func importTypeOf(spec *ast.ImportSpec) string {
+	if strings.Trim(spec.Path.Value, `"`) == cgoImportPath {
+		return ImportTypeCgo
+	}
+	if spec.Name == nil {
+		return ImportTypeNormal
+	}
+	switch spec.Name.Name {
+	case "_":
+		return ImportTypeBlank
+	case ".":
+		return ImportTypeDot
+	default:
+		return ImportTypeNormal
+	}
+}
+
+// nodeImportTypes returns the sorted types of the imports in the src node which refer to packages in the dst node.
+func (m *Prelviz) nodeImportTypes(srcNodeName, dstNodeName string) []string {
+	typeMap := make(map[string]struct{})
+	m.walkNodeImports(srcNodeName, dstNodeName, func(importPath string, info *PackageInfo) {
+		for importType := range info.ImportTypeMap[importPath] {
+			typeMap[importType] = struct{}{}
+		}
+	})
+	importTypes := make([]string, 0, len(typeMap))
+	for importType := range typeMap {
+		importTypes = append(importTypes, importType)
+	}
+	sort.Strings(importTypes)
+	return importTypes
+}
+
+// isBlankImportOnly reports whether the dependency is made only by blank imports, which are drawn as dotted edges.
+func isBlankImportOnly(importTypes []string) bool {
+	return len(importTypes) == 1 && importTypes[0] == ImportTypeBlank
+}
+
+// hasUnqualifiedImport reports whether the dependency has a dot or cgo import, which are drawn as bold edges.
+func hasUnqualifiedImport(importTypes []string) bool {
+	for _, importType := range importTypes {
+		if importType == ImportTypeDot || importType == ImportTypeCgo {
+			return true
+		}
+	}
+	return false
+}
+
+// importTypesLabel returns the suffix of the edge label which lists the import types except normal, such as " (blank)".
+func importTypesLabel(importTypes []string) string {
+	types := make([]string, 0, len(importTypes))
+	for _, importType := range importTypes {
+		if importType != ImportTypeNormal {
+			types = append(types, importType)
+		}
+	}
+	if len(types) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(types, ","))
+}
@@ -0,0 +1,113 @@
+package prelviz
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func Test_importTypeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		spec *ast.ImportSpec
+		want string
+	}{
+		{
+			name: "normal",
+			spec: &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: `"fmt"`}},
+			want: ImportTypeNormal,
+		},
+		{
+			name: "normal: alias",
+			spec: &ast.ImportSpec{Name: ast.NewIdent("f"), Path: &ast.BasicLit{Kind: token.STRING, Value: `"fmt"`}},
+			want: ImportTypeNormal,
+		},
+		{
+			name: "normal: blank",
+			spec: &ast.ImportSpec{Name: ast.NewIdent("_"), Path: &ast.BasicLit{Kind: token.STRING, Value: `"github.com/lib/pq"`}},
+			want: ImportTypeBlank,
+		},
+		{
+			name: "normal: dot",
+			spec: &ast.ImportSpec{Name: ast.NewIdent("."), Path: &ast.BasicLit{Kind: token.STRING, Value: `"strings"`}},
+			want: ImportTypeDot,
+		},
+		{
+			name: "normal: cgo",
+			spec: &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: `"C"`}},
+			want: ImportTypeCgo,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := importTypeOf(tt.spec); got != tt.want {
+				t.Errorf("importTypeOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrelviz_nodeImportTypes(t *testing.T) {
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"app": {
+				Name:          "app",
+				DirectoryPath: "app",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/driver": {},
+					"mod/domain": {"User": {}},
+				},
+				ImportTypeMap: map[string]map[string]struct{}{
+					"mod/driver": {ImportTypeBlank: {}},
+					"mod/domain": {ImportTypeNormal: {}, ImportTypeDot: {}},
+				},
+			},
+			"driver": {Name: "driver", DirectoryPath: "driver"},
+			"domain": {Name: "domain", DirectoryPath: "domain"},
+		},
+		config: &Config{
+			NgRelationMap: map[string]map[string]struct{}{
+				"mod/app": {"mod/driver": {}},
+			},
+			ExcludePackageMap: make(map[string]struct{}),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		dst       string
+		want      []string
+		wantLabel string
+	}{
+		{
+			name:      "normal: blank import only",
+			dst:       "mod/driver",
+			want:      []string{ImportTypeBlank},
+			wantLabel: " (blank)",
+		},
+		{
+			name:      "normal: dot and normal imports",
+			dst:       "mod/domain",
+			want:      []string{ImportTypeDot, ImportTypeNormal},
+			wantLabel: " (dot)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.nodeImportTypes("mod/app", tt.dst)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Prelviz.nodeImportTypes() = %v, want %v", got, tt.want)
+			}
+			if label := importTypesLabel(got); label != tt.wantLabel {
+				t.Errorf("importTypesLabel() = %v, want %v", label, tt.wantLabel)
+			}
+		})
+	}
+
+	violations := m.violations()
+	if len(violations) != 1 || violations[0].To != "mod/driver" || !reflect.DeepEqual(violations[0].ImportTypes, []string{ImportTypeBlank}) {
+		t.Errorf("Prelviz.violations() = %v, want the blank import of mod/driver", violations)
+	}
+}
@@ -0,0 +1,149 @@
+package prelviz
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// JSONSchemaVersion is the version of the json format. It is incremented when the schema changes incompatibly.
+const JSONSchemaVersion = 1
+
+// JSONGraph is the package relation graph in the json format.
+type JSONGraph struct {
+	SchemaVersion int `json:"schema_version"`
+	// Module is the module of the project. It is empty for a workspace of multiple modules, which has Modules instead.
+	Module string `json:"module"`
+	// Modules is the modules of a workspace. It is omitted for a single module project.
+	Modules []string    `json:"modules,omitempty"`
+	Nodes   []*JSONNode `json:"nodes"`
+	Edges   []*JSONEdge `json:"edges"`
+}
+
+type JSONNode struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	DirectoryPath      string `json:"directory_path"`
+	IsGrouping         bool   `json:"is_grouping"`
+	ContainsPackageNum int    `json:"contains_package_num"`
+	Layer              string `json:"layer,omitempty"`
+	Cyclic             bool   `json:"cyclic"`
+	IsTest             bool   `json:"is_test"`
+	Module             string `json:"module,omitempty"`
+	IsExternal         bool   `json:"is_external"`
+	IsStdlib           bool   `json:"is_stdlib"`
+	// StdlibUsages is the identifiers used from each package of the standard library in the stdlib annotation mode.
+	StdlibUsages map[string][]string `json:"stdlib_usages,omitempty"`
+}
+
+type JSONEdge struct {
+	From           string              `json:"from"`
+	To             string              `json:"to"`
+	DepCount       int                 `json:"dep_count"`
+	ReferenceCount int                 `json:"reference_count"`
+	FileCount      int                 `json:"file_count"`
+	Identifiers    map[string][]string `json:"identifiers"`
+	Violation      bool                `json:"violation"`
+	ViolatedRule   string              `json:"violated_rule,omitempty"`
+	// References is the file:line of the imports and usages which make the violation. It is omitted for the edges without violations.
+	References      []*Reference                 `json:"references,omitempty"`
+	Cyclic          bool                         `json:"cyclic"`
+	TestOnly        bool                         `json:"test_only"`
+	ImportTypes     []string                     `json:"import_types"`
+	KindCounts      map[string]int               `json:"kind_counts,omitempty"`
+	IdentifierKinds map[string]map[string]string `json:"identifier_kinds,omitempty"`
+}
+
+func (m *Prelviz) writeJSON(cycles [][]string) error {
+	encoder := json.NewEncoder(m.output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m.jsonGraph(cycles))
+}
+
+func (m *Prelviz) jsonGraph(cycles [][]string) *JSONGraph {
+	cyclicNodeMap := cyclicNodeMap(cycles)
+
+	nodes := make([]*JSONNode, 0)
+	for nodeName, info := range m.nodeInfoMap() {
+		_, cyclic := cyclicNodeMap[nodeName]
+		nodes = append(nodes, &JSONNode{
+			ID:                 nodeName,
+			Name:               info.Name,
+			DirectoryPath:      info.DirectoryPath,
+			IsGrouping:         info.IsGrouping,
+			ContainsPackageNum: info.ContainsPackageNum,
+			Layer:              info.Layer,
+			Cyclic:             cyclic,
+			IsTest:             info.IsTest,
+			Module:             info.Module,
+			IsExternal:         info.IsExternal,
+			IsStdlib:           info.IsStdlib,
+			StdlibUsages:       stdlibUsages(info),
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+
+	edges := make([]*JSONEdge, 0)
+	testOnlyRelationMap := m.nodeTestOnlyRelationMap()
+	referenceCountMap := m.nodeRelationCountMapBy(LabelReferences, true)
+	fileCountMap := m.nodeRelationCountMapBy(LabelFiles, true)
+	for srcNodeName, relationMap := range m.nodeRelationCountMapBy(LabelIdentifiers, true) {
+		for dstNodeName, relationNum := range relationMap {
+			identifiers := make(map[string][]string)
+			for importPath, usageMap := range m.nodeImportUsageMap(srcNodeName, dstNodeName) {
+				identifiers[importPath] = sortedKeys(usageMap)
+			}
+			rule := m.config.ViolatedRule(srcNodeName, dstNodeName)
+			edge := &JSONEdge{
+				From:           srcNodeName,
+				To:             dstNodeName,
+				DepCount:       relationNum,
+				ReferenceCount: referenceCountMap[srcNodeName][dstNodeName],
+				FileCount:      fileCountMap[srcNodeName][dstNodeName],
+				Identifiers:    identifiers,
+				Violation:      rule != "",
+				ViolatedRule:   rule,
+				Cyclic:         m.isCyclicRelation(cyclicNodeMap, srcNodeName, dstNodeName),
+				TestOnly:       isTestOnlyRelation(testOnlyRelationMap, srcNodeName, dstNodeName),
+				ImportTypes:    m.nodeImportTypes(srcNodeName, dstNodeName),
+			}
+			if edge.Violation {
+				edge.References = m.nodeReferences(srcNodeName, dstNodeName)
+			}
+			if usageKindMap := m.nodeUsageKindMap(srcNodeName, dstNodeName); len(usageKindMap) > 0 {
+				edge.KindCounts = identifierKindCounts(usageKindMap)
+				edge.IdentifierKinds = usageKindMap
+			}
+			edges = append(edges, edge)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+
+	graph := &JSONGraph{
+		SchemaVersion: JSONSchemaVersion,
+		Module:        m.projectModuleName,
+		Nodes:         nodes,
+		Edges:         edges,
+	}
+	if workspace := m.moduleWorkspace(); workspace.IsMultiModule() {
+		graph.Modules = workspace.ModulePaths()
+	}
+	return graph
+}
+
+func stdlibUsages(info *NodeInfo) map[string][]string {
+	if len(info.StdlibUsageMap) == 0 {
+		return nil
+	}
+	usages := make(map[string][]string, len(info.StdlibUsageMap))
+	for importPath, usageMap := range info.StdlibUsageMap {
+		usages[importPath] = sortedKeys(usageMap)
+	}
+	return usages
+}
@@ -0,0 +1,68 @@
+package prelviz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrelviz_jsonGraph(t *testing.T) {
+	m := newSamplePrelviz()
+	want := &JSONGraph{
+		SchemaVersion: JSONSchemaVersion,
+		Module:        "mod",
+		Nodes: []*JSONNode{
+			{
+				ID:                 "mod/sample/dst2",
+				Name:               "dst2",
+				DirectoryPath:      "sample/dst2",
+				ContainsPackageNum: 1,
+			},
+			{
+				ID:                 "mod/sample/grouping",
+				DirectoryPath:      "sample/grouping",
+				IsGrouping:         true,
+				ContainsPackageNum: 1,
+			},
+			{
+				ID:                 "mod/sample/src",
+				Name:               "src",
+				DirectoryPath:      "sample/src",
+				ContainsPackageNum: 1,
+			},
+		},
+		Edges: []*JSONEdge{
+			{
+				From:           "mod/sample/src",
+				To:             "mod/sample/dst2",
+				DepCount:       1,
+				ReferenceCount: 1,
+				FileCount:      1,
+				Identifiers: map[string][]string{
+					"mod/sample/dst2": {"Sample3"},
+				},
+				ImportTypes: []string{ImportTypeNormal},
+			},
+			{
+				From:           "mod/sample/src",
+				To:             "mod/sample/grouping",
+				DepCount:       2,
+				ReferenceCount: 2,
+				FileCount:      1,
+				Identifiers: map[string][]string{
+					"mod/sample/grouping/dst1": {"Sample1", "Sample2"},
+				},
+				Violation:    true,
+				ViolatedRule: RuleNgRelation,
+				References: []*Reference{
+					{ImportPath: "mod/sample/grouping/dst1", Position: Position{FilePath: "sample/src/src.go", Line: 7, Column: 2}},
+					{ImportPath: "mod/sample/grouping/dst1", Identifier: "Sample2", Position: Position{FilePath: "sample/src/src.go", Line: 13, Column: 7, Func: "Run"}},
+					{ImportPath: "mod/sample/grouping/dst1", Identifier: "Sample1", Position: Position{FilePath: "sample/src/src.go", Line: 14, Column: 2, Func: "Run"}},
+				},
+				ImportTypes: []string{ImportTypeNormal},
+			},
+		},
+	}
+	if got := m.jsonGraph(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Prelviz.jsonGraph() = %+v, want %+v", got, want)
+	}
+}
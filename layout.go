@@ -0,0 +1,500 @@
+package prelviz
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// point is a coordinate in the image. The origin is the top left corner.
+type point struct {
+	X, Y float64
+}
+
+type layoutNode struct {
+	ID     string
+	Width  float64
+	Height float64
+	// X and Y are the center of the node, set by layoutGraph.
+	X, Y float64
+}
+
+type layoutEdge struct {
+	From, To string
+	// Invisible edges only affect the layout, such as ranking layers.
+	Invisible bool
+	// Points are the polyline from the border of From to the border of To, set by layoutGraph.
+	Points []point
+}
+
+const (
+	layoutMargin  = 40.0
+	layoutNodeSep = 40.0
+	layoutRankSep = 80.0
+)
+
+// layoutGraph sets the coordinates of the nodes and the edges by the layout engine and returns the size of the graph.
+// The engines are approximations of graphviz's ones implemented in Go.
+func layoutGraph(layout string, nodes []*layoutNode, edges []*layoutEdge) (float64, float64, error) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+	switch layout {
+	case "dot", "":
+		layoutLayered(nodes, edges)
+	case "neato", "fdp", "sfdp":
+		layoutForce(nodes, edges)
+		routeStraightEdges(nodes, edges)
+	case "circo":
+		layoutCircle(nodes)
+		routeStraightEdges(nodes, edges)
+	case "twopi":
+		layoutRadial(nodes, edges)
+		routeStraightEdges(nodes, edges)
+	default:
+		return 0, 0, fmt.Errorf("unsupported layout: %s", layout)
+	}
+	width, height := normalizeLayout(nodes, edges)
+	return width, height, nil
+}
+
+// layoutLayered is a Sugiyama style layout which ranks nodes from top to bottom along the edges.
+func layoutLayered(nodes []*layoutNode, edges []*layoutEdge) {
+	if len(nodes) == 0 {
+		return
+	}
+	indexMap := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		indexMap[n.ID] = i
+	}
+
+	// remove cycles by reversing back edges found by DFS
+	type directedEdge struct {
+		edge     *layoutEdge
+		from, to int
+		reversed bool
+	}
+	validEdges := make([]*layoutEdge, 0, len(edges))
+	for _, e := range edges {
+		_, fromOk := indexMap[e.From]
+		_, toOk := indexMap[e.To]
+		if fromOk && toOk && e.From != e.To {
+			validEdges = append(validEdges, e)
+		}
+	}
+	outMap := make(map[int][]int)
+	for _, e := range validEdges {
+		from, to := indexMap[e.From], indexMap[e.To]
+		outMap[from] = append(outMap[from], to)
+	}
+	state := make([]int, len(nodes))
+	backEdgeMap := make(map[[2]int]bool)
+	var visit func(v int)
+	visit = func(v int) {
+		state[v] = 1
+		for _, w := range outMap[v] {
+			switch state[w] {
+			case 0:
+				visit(w)
+			case 1:
+				backEdgeMap[[2]int{v, w}] = true
+			}
+		}
+		state[v] = 2
+	}
+	for v := range nodes {
+		if state[v] == 0 {
+			visit(v)
+		}
+	}
+	directedEdges := make([]*directedEdge, 0, len(validEdges))
+	for _, e := range validEdges {
+		from, to := indexMap[e.From], indexMap[e.To]
+		if backEdgeMap[[2]int{from, to}] {
+			directedEdges = append(directedEdges, &directedEdge{edge: e, from: to, to: from, reversed: true})
+		} else {
+			directedEdges = append(directedEdges, &directedEdge{edge: e, from: from, to: to})
+		}
+	}
+
+	// rank nodes by the longest path from sources
+	ranks := make([]int, len(nodes))
+	inDegrees := make([]int, len(nodes))
+	acyclicOutMap := make(map[int][]int)
+	for _, e := range directedEdges {
+		acyclicOutMap[e.from] = append(acyclicOutMap[e.from], e.to)
+		inDegrees[e.to]++
+	}
+	queue := make([]int, 0)
+	for v := range nodes {
+		if inDegrees[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range acyclicOutMap[v] {
+			ranks[w] = max(ranks[w], ranks[v]+1)
+			inDegrees[w]--
+			if inDegrees[w] == 0 {
+				queue = append(queue, w)
+			}
+		}
+	}
+
+	// split long edges by virtual nodes so that every edge connects adjacent ranks
+	type virtualNode struct {
+		rank   int
+		x      float64
+		width  float64
+		height float64
+		order  float64
+	}
+	vnodes := make([]*virtualNode, len(nodes))
+	for i, n := range nodes {
+		vnodes[i] = &virtualNode{rank: ranks[i], width: n.Width, height: n.Height}
+	}
+	chains := make([][]int, len(directedEdges))
+	upMap := make(map[int][]int)
+	downMap := make(map[int][]int)
+	for i, e := range directedEdges {
+		chain := []int{e.from}
+		for r := ranks[e.from] + 1; r < ranks[e.to]; r++ {
+			vnodes = append(vnodes, &virtualNode{rank: r})
+			chain = append(chain, len(vnodes)-1)
+		}
+		chain = append(chain, e.to)
+		for j := 0; j+1 < len(chain); j++ {
+			downMap[chain[j]] = append(downMap[chain[j]], chain[j+1])
+			upMap[chain[j+1]] = append(upMap[chain[j+1]], chain[j])
+		}
+		chains[i] = chain
+	}
+
+	maxRank := 0
+	for _, v := range vnodes {
+		maxRank = max(maxRank, v.rank)
+	}
+	rankMembers := make([][]int, maxRank+1)
+	for i, v := range vnodes {
+		rankMembers[v.rank] = append(rankMembers[v.rank], i)
+	}
+
+	// order nodes in each rank by the barycenter of the neighbors to reduce crossings
+	setOrders := func(members []int) {
+		for i, v := range members {
+			vnodes[v].order = float64(i)
+		}
+	}
+	for _, members := range rankMembers {
+		setOrders(members)
+	}
+	barycenterSort := func(members []int, neighborMap map[int][]int) {
+		barycenterMap := make(map[int]float64, len(members))
+		for _, v := range members {
+			neighbors := neighborMap[v]
+			if len(neighbors) == 0 {
+				barycenterMap[v] = vnodes[v].order
+				continue
+			}
+			sum := 0.0
+			for _, w := range neighbors {
+				sum += vnodes[w].order
+			}
+			barycenterMap[v] = sum / float64(len(neighbors))
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			return barycenterMap[members[i]] < barycenterMap[members[j]]
+		})
+		setOrders(members)
+	}
+	for iter := 0; iter < 8; iter++ {
+		if iter%2 == 0 {
+			for r := 1; r <= maxRank; r++ {
+				barycenterSort(rankMembers[r], upMap)
+			}
+		} else {
+			for r := maxRank - 1; r >= 0; r-- {
+				barycenterSort(rankMembers[r], downMap)
+			}
+		}
+	}
+
+	// assign x by packing each rank from left and then pulling nodes toward their neighbors
+	pack := func(members []int) {
+		x := 0.0
+		for _, v := range members {
+			vnodes[v].x = x + vnodes[v].width/2
+			x += vnodes[v].width + layoutNodeSep
+		}
+	}
+	for _, members := range rankMembers {
+		pack(members)
+	}
+	resolveOverlaps := func(members []int) {
+		for i := 1; i < len(members); i++ {
+			prev, v := vnodes[members[i-1]], vnodes[members[i]]
+			minX := prev.x + prev.width/2 + layoutNodeSep + v.width/2
+			if v.x < minX {
+				v.x = minX
+			}
+		}
+	}
+	for iter := 0; iter < 8; iter++ {
+		neighborMap := upMap
+		ranksInOrder := make([]int, 0, maxRank+1)
+		if iter%2 == 0 {
+			for r := 1; r <= maxRank; r++ {
+				ranksInOrder = append(ranksInOrder, r)
+			}
+		} else {
+			neighborMap = downMap
+			for r := maxRank - 1; r >= 0; r-- {
+				ranksInOrder = append(ranksInOrder, r)
+			}
+		}
+		for _, r := range ranksInOrder {
+			members := rankMembers[r]
+			for _, v := range members {
+				neighbors := neighborMap[v]
+				if len(neighbors) == 0 {
+					continue
+				}
+				sum := 0.0
+				for _, w := range neighbors {
+					sum += vnodes[w].x
+				}
+				vnodes[v].x = sum / float64(len(neighbors))
+			}
+			resolveOverlaps(members)
+		}
+	}
+
+	// assign y by the tallest node in each rank
+	rankYs := make([]float64, maxRank+1)
+	y := 0.0
+	for r, members := range rankMembers {
+		height := 0.0
+		for _, v := range members {
+			height = max(height, vnodes[v].height)
+		}
+		rankYs[r] = y + height/2
+		y += height + layoutRankSep
+	}
+	for i, n := range nodes {
+		n.X, n.Y = vnodes[i].x, rankYs[vnodes[i].rank]
+	}
+
+	for i, e := range directedEdges {
+		chain := chains[i]
+		points := make([]point, 0, len(chain))
+		from, to := nodes[chain[0]], nodes[chain[len(chain)-1]]
+		points = append(points, point{X: from.X, Y: from.Y + from.Height/2})
+		for _, v := range chain[1 : len(chain)-1] {
+			points = append(points, point{X: vnodes[v].x, Y: rankYs[vnodes[v].rank]})
+		}
+		points = append(points, point{X: to.X, Y: to.Y - to.Height/2})
+		if e.reversed {
+			for l, r := 0, len(points)-1; l < r; l, r = l+1, r-1 {
+				points[l], points[r] = points[r], points[l]
+			}
+		}
+		e.edge.Points = points
+	}
+}
+
+// layoutForce is a Fruchterman-Reingold force directed layout.
+func layoutForce(nodes []*layoutNode, edges []*layoutEdge) {
+	if len(nodes) == 0 {
+		return
+	}
+	layoutCircle(nodes)
+	indexMap := make(map[string]int, len(nodes))
+	size := 0.0
+	for i, n := range nodes {
+		indexMap[n.ID] = i
+		size = max(size, n.Width, n.Height)
+	}
+	k := size + layoutNodeSep
+	temperature := k * math.Sqrt(float64(len(nodes)))
+	for iter := 0; iter < 300; iter++ {
+		dx := make([]float64, len(nodes))
+		dy := make([]float64, len(nodes))
+		for i := range nodes {
+			for j := i + 1; j < len(nodes); j++ {
+				x, y := nodes[i].X-nodes[j].X, nodes[i].Y-nodes[j].Y
+				d := math.Max(math.Hypot(x, y), 0.01)
+				f := k * k / d
+				dx[i] += x / d * f
+				dy[i] += y / d * f
+				dx[j] -= x / d * f
+				dy[j] -= y / d * f
+			}
+		}
+		for _, e := range edges {
+			i, iOk := indexMap[e.From]
+			j, jOk := indexMap[e.To]
+			if !iOk || !jOk || i == j {
+				continue
+			}
+			x, y := nodes[i].X-nodes[j].X, nodes[i].Y-nodes[j].Y
+			d := math.Max(math.Hypot(x, y), 0.01)
+			f := d * d / k
+			dx[i] -= x / d * f
+			dy[i] -= y / d * f
+			dx[j] += x / d * f
+			dy[j] += y / d * f
+		}
+		for i, n := range nodes {
+			d := math.Max(math.Hypot(dx[i], dy[i]), 0.01)
+			n.X += dx[i] / d * math.Min(d, temperature)
+			n.Y += dy[i] / d * math.Min(d, temperature)
+		}
+		temperature *= 0.98
+	}
+}
+
+// layoutCircle places the nodes on a circle.
+func layoutCircle(nodes []*layoutNode) {
+	if len(nodes) == 1 {
+		return
+	}
+	circumference := 0.0
+	for _, n := range nodes {
+		circumference += math.Max(n.Width, n.Height) + layoutNodeSep
+	}
+	radius := circumference / (2 * math.Pi)
+	for i, n := range nodes {
+		angle := 2*math.Pi*float64(i)/float64(len(nodes)) - math.Pi/2
+		n.X, n.Y = radius*math.Cos(angle), radius*math.Sin(angle)
+	}
+}
+
+// layoutRadial places the nodes on circles by the distance from the node which has the most edges.
+func layoutRadial(nodes []*layoutNode, edges []*layoutEdge) {
+	if len(nodes) == 0 {
+		return
+	}
+	neighborMap := make(map[string][]string)
+	for _, e := range edges {
+		if e.From == e.To {
+			continue
+		}
+		neighborMap[e.From] = append(neighborMap[e.From], e.To)
+		neighborMap[e.To] = append(neighborMap[e.To], e.From)
+	}
+	root := nodes[0]
+	for _, n := range nodes {
+		if len(neighborMap[n.ID]) > len(neighborMap[root.ID]) {
+			root = n
+		}
+	}
+
+	depthMap := map[string]int{root.ID: 0}
+	rings := [][]string{{root.ID}}
+	for len(rings[len(rings)-1]) > 0 {
+		next := make([]string, 0)
+		for _, id := range rings[len(rings)-1] {
+			neighbors := append([]string(nil), neighborMap[id]...)
+			sort.Strings(neighbors)
+			for _, neighbor := range neighbors {
+				if _, ok := depthMap[neighbor]; ok {
+					continue
+				}
+				depthMap[neighbor] = len(rings)
+				next = append(next, neighbor)
+			}
+		}
+		rings = append(rings, next)
+	}
+	rings = rings[:len(rings)-1]
+	unreached := make([]string, 0)
+	for _, n := range nodes {
+		if _, ok := depthMap[n.ID]; !ok {
+			unreached = append(unreached, n.ID)
+		}
+	}
+	if len(unreached) > 0 {
+		rings = append(rings, unreached)
+	}
+
+	nodeMap := make(map[string]*layoutNode, len(nodes))
+	size := 0.0
+	for _, n := range nodes {
+		nodeMap[n.ID] = n
+		size = max(size, n.Width, n.Height)
+	}
+	radius := 0.0
+	for depth, ring := range rings {
+		if depth > 0 {
+			radius = math.Max(radius+size+layoutRankSep, float64(len(ring))*(size+layoutNodeSep)/(2*math.Pi))
+		}
+		for i, id := range ring {
+			angle := 2*math.Pi*float64(i)/float64(len(ring)) - math.Pi/2
+			nodeMap[id].X, nodeMap[id].Y = radius*math.Cos(angle), radius*math.Sin(angle)
+		}
+	}
+}
+
+// routeStraightEdges sets a straight line between the borders of the nodes to the edges.
+func routeStraightEdges(nodes []*layoutNode, edges []*layoutEdge) {
+	nodeMap := make(map[string]*layoutNode, len(nodes))
+	for _, n := range nodes {
+		nodeMap[n.ID] = n
+	}
+	for _, e := range edges {
+		from, to := nodeMap[e.From], nodeMap[e.To]
+		if from == nil || to == nil || from == to {
+			continue
+		}
+		e.Points = []point{clipToBorder(from, to.X, to.Y), clipToBorder(to, from.X, from.Y)}
+	}
+}
+
+// clipToBorder returns the intersection of the border of the node and the line from its center to (x, y).
+func clipToBorder(n *layoutNode, x, y float64) point {
+	dx, dy := x-n.X, y-n.Y
+	if dx == 0 && dy == 0 {
+		return point{X: n.X, Y: n.Y}
+	}
+	scale := math.Inf(1)
+	if dx != 0 {
+		scale = math.Min(scale, n.Width/2/math.Abs(dx))
+	}
+	if dy != 0 {
+		scale = math.Min(scale, n.Height/2/math.Abs(dy))
+	}
+	return point{X: n.X + dx*scale, Y: n.Y + dy*scale}
+}
+
+// normalizeLayout moves the graph to have the margin at the top left corner and returns the size of the graph.
+func normalizeLayout(nodes []*layoutNode, edges []*layoutEdge) (float64, float64) {
+	if len(nodes) == 0 {
+		return 2 * layoutMargin, 2 * layoutMargin
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, n := range nodes {
+		minX, minY = math.Min(minX, n.X-n.Width/2), math.Min(minY, n.Y-n.Height/2)
+		maxX, maxY = math.Max(maxX, n.X+n.Width/2), math.Max(maxY, n.Y+n.Height/2)
+	}
+	for _, e := range edges {
+		for _, p := range e.Points {
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+		}
+	}
+	offsetX, offsetY := layoutMargin-minX, layoutMargin-minY
+	for _, n := range nodes {
+		n.X += offsetX
+		n.Y += offsetY
+	}
+	for _, e := range edges {
+		for i := range e.Points {
+			e.Points[i].X += offsetX
+			e.Points[i].Y += offsetY
+		}
+	}
+	return maxX - minX + 2*layoutMargin, maxY - minY + 2*layoutMargin
+}
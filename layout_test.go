@@ -0,0 +1,72 @@
+package prelviz
+
+import "testing"
+
+func Test_layoutGraph(t *testing.T) {
+	newNodes := func() []*layoutNode {
+		return []*layoutNode{
+			{ID: "mod/a", Width: 100, Height: 40},
+			{ID: "mod/b", Width: 100, Height: 40},
+			{ID: "mod/c", Width: 100, Height: 40},
+		}
+	}
+	newEdges := func() []*layoutEdge {
+		return []*layoutEdge{
+			{From: "mod/a", To: "mod/b"},
+			{From: "mod/b", To: "mod/c"},
+			{From: "mod/a", To: "mod/c"},
+		}
+	}
+	for _, layout := range []string{"dot", "neato", "fdp", "sfdp", "twopi", "circo"} {
+		t.Run("normal: "+layout, func(t *testing.T) {
+			nodes, edges := newNodes(), newEdges()
+			width, height, err := layoutGraph(layout, nodes, edges)
+			if err != nil {
+				t.Fatalf("layoutGraph() error = %v", err)
+			}
+			for _, n := range nodes {
+				if n.X-n.Width/2 < 0 || n.X+n.Width/2 > width || n.Y-n.Height/2 < 0 || n.Y+n.Height/2 > height {
+					t.Errorf("layoutGraph() node %s is out of the graph", n.ID)
+				}
+			}
+			for i, n := range nodes {
+				for _, o := range nodes[i+1:] {
+					if abs(n.X-o.X) < (n.Width+o.Width)/2 && abs(n.Y-o.Y) < (n.Height+o.Height)/2 {
+						t.Errorf("layoutGraph() node %s overlaps %s", n.ID, o.ID)
+					}
+				}
+			}
+			for _, e := range edges {
+				if len(e.Points) < 2 {
+					t.Errorf("layoutGraph() edge %s -> %s has no points", e.From, e.To)
+				}
+			}
+		})
+	}
+
+	t.Run("normal: dot ranks nodes along edges", func(t *testing.T) {
+		nodes, edges := newNodes(), newEdges()
+		if _, _, err := layoutGraph("dot", nodes, edges); err != nil {
+			t.Fatalf("layoutGraph() error = %v", err)
+		}
+		if !(nodes[0].Y < nodes[1].Y && nodes[1].Y < nodes[2].Y) {
+			t.Errorf("layoutGraph() y = %v, %v, %v, want ascending", nodes[0].Y, nodes[1].Y, nodes[2].Y)
+		}
+		if got := len(edges[2].Points); got != 3 {
+			t.Errorf("layoutGraph() points of the edge skipping a rank = %d, want 3", got)
+		}
+	})
+
+	t.Run("anomaly: unsupported layout", func(t *testing.T) {
+		if _, _, err := layoutGraph("unknown", newNodes(), newEdges()); err == nil {
+			t.Errorf("layoutGraph() error = nil, want error")
+		}
+	})
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,233 @@
+package prelviz
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	// LoaderAST parses every go file by itself. It is fast, but guesses the package of an import by the last element of the import path.
+	LoaderAST = "ast"
+	// LoaderPackages loads the packages by golang.org/x/tools/go/packages. It resolves the real import paths,
+	// the package names and the build constraints, but needs the go command and the dependencies of the project.
+	// It is much slower than LoaderAST, since every dependency including the standard library is type checked from source.
+	LoaderPackages = "packages"
+)
+
+// LoadOption selects the go files to load. A nil LoadOption selects every go file except tests.
+type LoadOption struct {
+	// Build is the build constraints which select go files. If it is nil, every go file is selected.
+	Build *BuildOption
+	// IncludeTests selects _test.go files.
+	IncludeTests bool
+	// SkipGenerated skips the files which have the "// Code generated ... DO NOT EDIT." comment.
+	SkipGenerated bool
+}
+
+func (o *LoadOption) buildOption() *BuildOption {
+	if o == nil {
+		return nil
+	}
+	return o.Build
+}
+
+func (o *LoadOption) includeTests() bool {
+	return o != nil && o.IncludeTests
+}
+
+func (o *LoadOption) skipGenerated() bool {
+	return o != nil && o.SkipGenerated
+}
+
+// validateLoader returns an error for an unsupported loader.
+func validateLoader(loader string) error {
+	switch loader {
+	case LoaderAST, LoaderPackages, "":
+		return nil
+	default:
+		return fmt.Errorf("unsupported loader: %s", loader)
+	}
+}
+
+// loadPackageInfoMap loads the packages of the modules in the workspace by the loader.
+// When the packages loader fails, it warns to errOutput and falls back to the ast loader.
+func loadPackageInfoMap(projectDirectoryPath string, workspace *Workspace, loader string, loadOption *LoadOption, errOutput io.Writer) (map[string]*PackageInfo, error) {
+	switch loader {
+	case LoaderAST, "":
+		return newPackageInfoMapOfDirectories(projectDirectoryPath, workspace.DirectoryPaths(), loadOption)
+	case LoaderPackages:
+		packageInfoMap, err := loadPackages(projectDirectoryPath, packagePatterns(workspace), loadOption)
+		if err == nil {
+			return packageInfoMap, nil
+		}
+		if errOutput != nil {
+			fmt.Fprintf(errOutput, "warning: failed to load packages, so the ast loader is used instead: %v\n", err)
+		}
+		return newPackageInfoMapOfDirectories(projectDirectoryPath, workspace.DirectoryPaths(), loadOption)
+	default:
+		return nil, fmt.Errorf("unsupported loader: %s", loader)
+	}
+}
+
+// NewPackageInfoMapWithPackages loads the packages of the project by golang.org/x/tools/go/packages.
+// Usages are resolved by the type information, and files excluded by build constraints are ignored.
+// The build option is passed to the go command as -tags, GOOS and GOARCH. Directories are selected by the go command,
+// so vendor, testdata, directories beginning with _ or . and nested modules are ignored.
+func NewPackageInfoMapWithPackages(projectDirectoryPath string, loadOption *LoadOption) (map[string]*PackageInfo, error) {
+	return loadPackages(projectDirectoryPath, []string{"./..."}, loadOption)
+}
+
+// packagePatterns returns the patterns of the go command which match every package of the modules in the workspace.
+// The go command finds go.work at the project directory and loads the packages of all the modules at once.
+func packagePatterns(workspace *Workspace) []string {
+	return lo.Map(workspace.DirectoryPaths(), func(dirPath string, _ int) string {
+		return "./" + path.Join(dirPath, "...")
+	})
+}
+
+func loadPackages(projectDirectoryPath string, patterns []string, loadOption *LoadOption) (map[string]*PackageInfo, error) {
+	absProjectDirectoryPath, err := filepath.Abs(projectDirectoryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	buildFlags, env := loadOption.buildOption().buildFlags()
+	// the dependencies are type checked from source with NeedDeps, which takes most of the time. Their types are not read
+	// from the export data, because go/packages exits the process when it can not decode the export data of a newer go command.
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax,
+		Dir:        absProjectDirectoryPath,
+		BuildFlags: buildFlags,
+		Env:        env,
+		Tests:      loadOption.includeTests(),
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, 0)
+	fileInfos := make([]*PackageInfo, 0)
+	// a package with tests is loaded twice as itself and the test variant, so a file is collected once.
+	loadedMap := make(map[string]struct{})
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.PkgPath, ".test") {
+			// the generated main package of the test binary
+			continue
+		}
+		for _, pkgErr := range pkg.Errors {
+			// type errors do not prevent resolving the usages which are type checked.
+			if pkgErr.Kind != packages.TypeError {
+				errs = append(errs, pkgErr)
+			}
+		}
+		goFileMap := lo.SliceToMap(pkg.GoFiles, func(filePath string) (string, struct{}) { return filePath, struct{}{} })
+		addFile := func(fset *token.FileSet, f *ast.File, filePath string, importPathOf func(ident *ast.Ident) (string, bool), kindOf func(sel *ast.Ident) string) {
+			relativeFilePath, err := filepath.Rel(absProjectDirectoryPath, filePath)
+			if err != nil || strings.HasPrefix(relativeFilePath, "..") {
+				return
+			}
+			if _, ok := loadedMap[relativeFilePath]; ok {
+				return
+			}
+			if loadOption.skipGenerated() && ast.IsGenerated(f) {
+				return
+			}
+			loadedMap[relativeFilePath] = struct{}{}
+			fileInfos = append(fileInfos, newFilePackageInfo(fset, f, relativeFilePath, importPathOf, kindOf))
+		}
+		typesInfo := pkg.TypesInfo
+		for _, f := range pkg.Syntax {
+			filePath := pkg.Fset.PositionFor(f.Pos(), false).Filename
+			if _, ok := goFileMap[filePath]; !ok {
+				// files generated by cgo replace the originals which import "C".
+				continue
+			}
+			addFile(pkg.Fset, f, filePath, func(ident *ast.Ident) (string, bool) {
+				pkgName, ok := typesInfo.Uses[ident].(*types.PkgName)
+				if !ok {
+					return "", false
+				}
+				return pkgName.Imported().Path(), true
+			}, func(sel *ast.Ident) string {
+				return identifierKindOf(typesInfo.Uses[sel])
+			})
+		}
+		compiledFileMap := lo.SliceToMap(pkg.CompiledGoFiles, func(filePath string) (string, struct{}) { return filePath, struct{}{} })
+		// the originals of the files generated by cgo keep the import of "C".
+		for _, filePath := range pkg.GoFiles {
+			if _, ok := compiledFileMap[filePath]; ok {
+				continue
+			}
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, filePath, nil, 0)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			importPathOf, kindOf := cgoFileResolvers(pkg, f)
+			addFile(fset, f, filePath, importPathOf, kindOf)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return mergePackageInfos(fileInfos), nil
+}
+
+// cgoFileResolvers returns the resolvers of the original go file of a cgo package, which has no type information
+// because cgo rewrites it before type checking. An import is resolved by the name of the imported package,
+// and the kind of a selected identifier is looked up in the scope of the package. The identifiers of "C" have no kind.
+func cgoFileResolvers(pkg *packages.Package, f *ast.File) (func(ident *ast.Ident) (string, bool), func(sel *ast.Ident) string) {
+	importPathMap := make(map[string]string)
+	for _, spec := range f.Imports {
+		importPath := strings.Trim(spec.Path.Value, `"`)
+		switch {
+		case spec.Name != nil:
+			importPathMap[spec.Name.Name] = importPath
+		case importPath == cgoImportPath:
+			importPathMap[cgoImportPath] = importPath
+		default:
+			if imported, ok := pkg.Imports[importPath]; ok {
+				importPathMap[imported.Name] = importPath
+			}
+		}
+	}
+	importPathOf := func(ident *ast.Ident) (string, bool) {
+		importPath, ok := importPathMap[ident.Name]
+		return importPath, ok
+	}
+
+	kindMap := make(map[*ast.Ident]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		x, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := x.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		importPath, ok := importPathOf(ident)
+		if !ok {
+			return true
+		}
+		if imported, ok := pkg.Imports[importPath]; ok && imported.Types != nil {
+			kindMap[x.Sel] = identifierKindOf(imported.Types.Scope().Lookup(x.Sel.Name))
+		}
+		return true
+	})
+	return importPathOf, func(sel *ast.Ident) string {
+		return kindMap[sel]
+	}
+}
@@ -0,0 +1,230 @@
+package prelviz
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestNewPackageInfoMapWithPackages(t *testing.T) {
+	type args struct {
+		projectDirectoryPath string
+		loadOption           *LoadOption
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    map[string]*PackageInfo
+		wantErr bool
+	}{
+		{
+			name: "resolve the package whose name differs from the directory and ignore build constrained files",
+			args: args{
+				projectDirectoryPath: "testdata/loader_test/valid",
+			},
+			want: map[string]*PackageInfo{
+				"app": {
+					Name:          "app",
+					DirectoryPath: "app",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"sample/yaml-go": {"Marshal": {}},
+					},
+					ImportPositionMap: map[string][]Position{
+						"sample/yaml-go": {{FilePath: "app/app.go", Line: 3, Column: 8}},
+					},
+					UsagePositionMap: map[string]map[string][]Position{
+						"sample/yaml-go": {"Marshal": {{FilePath: "app/app.go", Line: 6, Column: 9, Func: "Run"}}},
+					},
+					FilePaths: []string{"app/app.go"},
+					ImportTypeMap: map[string]map[string]struct{}{
+						"sample/yaml-go": {ImportTypeNormal: {}},
+					},
+					UsageKindMap: map[string]map[string]string{
+						"sample/yaml-go": {"Marshal": IdentifierKindFunc},
+					},
+					SymbolKindMap: map[string]string{"Run": IdentifierKindFunc},
+					SymbolUsageMap: map[string]map[string]map[string]struct{}{
+						"Run": {"sample/yaml-go": {"Marshal": {}}},
+					},
+				},
+				"yaml-go": {
+					Name:          "yaml",
+					DirectoryPath: "yaml-go",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"errors": {"New": {}},
+					},
+					ImportPositionMap: map[string][]Position{
+						"errors": {{FilePath: "yaml-go/yaml.go", Line: 3, Column: 8}},
+					},
+					UsagePositionMap: map[string]map[string][]Position{
+						"errors": {"New": {{FilePath: "yaml-go/yaml.go", Line: 6, Column: 14, Func: "Marshal"}}},
+					},
+					FilePaths: []string{"yaml-go/yaml.go"},
+					ImportTypeMap: map[string]map[string]struct{}{
+						"errors": {ImportTypeNormal: {}},
+					},
+					UsageKindMap: map[string]map[string]string{
+						"errors": {"New": IdentifierKindFunc},
+					},
+					SymbolKindMap: map[string]string{"Marshal": IdentifierKindFunc},
+					SymbolUsageMap: map[string]map[string]map[string]struct{}{
+						"Marshal": {"errors": {"New": {}}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "include the files by the build tags",
+			args: args{
+				projectDirectoryPath: "testdata/loader_test/valid",
+				loadOption:           &LoadOption{Build: &BuildOption{Tags: []string{"integration"}}},
+			},
+			want: map[string]*PackageInfo{
+				"app": {
+					Name:          "app",
+					DirectoryPath: "app",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"sample/yaml-go": {"Marshal": {}},
+						"fmt":            {"Println": {}},
+					},
+					ImportPositionMap: map[string][]Position{
+						"sample/yaml-go": {{FilePath: "app/app.go", Line: 3, Column: 8}},
+						"fmt":            {{FilePath: "app/integration.go", Line: 5, Column: 8}},
+					},
+					UsagePositionMap: map[string]map[string][]Position{
+						"sample/yaml-go": {"Marshal": {{FilePath: "app/app.go", Line: 6, Column: 9, Func: "Run"}}},
+						"fmt":            {"Println": {{FilePath: "app/integration.go", Line: 8, Column: 2, Func: "Integration"}}},
+					},
+					FilePaths: []string{"app/app.go", "app/integration.go"},
+					ImportTypeMap: map[string]map[string]struct{}{
+						"sample/yaml-go": {ImportTypeNormal: {}},
+						"fmt":            {ImportTypeNormal: {}},
+					},
+					UsageKindMap: map[string]map[string]string{
+						"sample/yaml-go": {"Marshal": IdentifierKindFunc},
+						"fmt":            {"Println": IdentifierKindFunc},
+					},
+					SymbolKindMap: map[string]string{"Run": IdentifierKindFunc, "Integration": IdentifierKindFunc},
+					SymbolUsageMap: map[string]map[string]map[string]struct{}{
+						"Run":         {"sample/yaml-go": {"Marshal": {}}},
+						"Integration": {"fmt": {"Println": {}}},
+					},
+				},
+				"yaml-go": {
+					Name:          "yaml",
+					DirectoryPath: "yaml-go",
+					ImportUsageMap: map[string]map[string]struct{}{
+						"errors": {"New": {}},
+					},
+					ImportPositionMap: map[string][]Position{
+						"errors": {{FilePath: "yaml-go/yaml.go", Line: 3, Column: 8}},
+					},
+					UsagePositionMap: map[string]map[string][]Position{
+						"errors": {"New": {{FilePath: "yaml-go/yaml.go", Line: 6, Column: 14, Func: "Marshal"}}},
+					},
+					FilePaths: []string{"yaml-go/yaml.go"},
+					ImportTypeMap: map[string]map[string]struct{}{
+						"errors": {ImportTypeNormal: {}},
+					},
+					UsageKindMap: map[string]map[string]string{
+						"errors": {"New": IdentifierKindFunc},
+					},
+					SymbolKindMap: map[string]string{"Marshal": IdentifierKindFunc},
+					SymbolUsageMap: map[string]map[string]map[string]struct{}{
+						"Marshal": {"errors": {"New": {}}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "not exist",
+			args: args{
+				projectDirectoryPath: "testdata/loader_test/not_exist",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPackageInfoMapWithPackages(tt.args.projectDirectoryPath, tt.args.loadOption)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewPackageInfoMapWithPackages() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewPackageInfoMapWithPackages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loadPackageInfoMap(t *testing.T) {
+	if _, err := loadPackageInfoMap("testdata/loader_test/valid", NewModuleWorkspace("sample"), "unknown", nil, nil); err == nil {
+		t.Errorf("loadPackageInfoMap() error = nil, want an error for an unsupported loader")
+	}
+	got, err := loadPackageInfoMap("testdata/loader_test/valid", NewModuleWorkspace("sample"), LoaderAST, nil, nil)
+	if err != nil {
+		t.Fatalf("loadPackageInfoMap() error = %v", err)
+	}
+	if _, ok := got["app"].ImportUsageMap["sample/yaml-go"]; ok {
+		t.Errorf("loadPackageInfoMap() with the ast loader resolves the usage of sample/yaml-go")
+	}
+}
+
+func Test_loadPackageInfoMap_workspace(t *testing.T) {
+	// the go command rejects -mod=mod in workspace mode.
+	t.Setenv("GOFLAGS", "")
+	workspace, err := NewWorkspace("testdata/module_test/workspace")
+	if err != nil {
+		t.Fatalf("NewWorkspace() error = %v", err)
+	}
+	for _, loader := range []string{LoaderAST, LoaderPackages} {
+		var errOutput bytes.Buffer
+		got, err := loadPackageInfoMap("testdata/module_test/workspace", workspace, loader, nil, &errOutput)
+		if err != nil {
+			t.Fatalf("loadPackageInfoMap() with the %s loader error = %v", loader, err)
+		}
+		if errOutput.Len() > 0 {
+			t.Errorf("loadPackageInfoMap() with the %s loader warns %s", loader, errOutput.String())
+		}
+		if _, ok := got["svc/b/app"].ImportUsageMap["example.com/a/domain"]["User"]; !ok {
+			t.Errorf("loadPackageInfoMap() with the %s loader = %v, want the usage of example.com/a/domain", loader, got)
+		}
+	}
+}
+
+func Test_loadPackageInfoMap_cgo(t *testing.T) {
+	t.Setenv("CGO_ENABLED", "1")
+	for _, loader := range []string{LoaderAST, LoaderPackages} {
+		var errOutput bytes.Buffer
+		got, err := loadPackageInfoMap("testdata/loader_test/cgo", NewModuleWorkspace("cgosample"), loader, nil, &errOutput)
+		if err != nil {
+			t.Fatalf("loadPackageInfoMap() with the %s loader error = %v", loader, err)
+		}
+		if errOutput.Len() > 0 {
+			t.Errorf("loadPackageInfoMap() with the %s loader warns %s", loader, errOutput.String())
+		}
+		info := got["native"]
+		wantImportUsageMap := map[string]map[string]struct{}{
+			"C":       {"malloc": {}, "free": {}},
+			"strings": {"ToUpper": {}},
+		}
+		if !reflect.DeepEqual(info.ImportUsageMap, wantImportUsageMap) {
+			t.Errorf("loadPackageInfoMap() with the %s loader ImportUsageMap = %v, want %v", loader, info.ImportUsageMap, wantImportUsageMap)
+		}
+		wantImportTypeMap := map[string]map[string]struct{}{
+			"C":       {ImportTypeCgo: {}},
+			"strings": {ImportTypeNormal: {}},
+		}
+		if !reflect.DeepEqual(info.ImportTypeMap, wantImportTypeMap) {
+			t.Errorf("loadPackageInfoMap() with the %s loader ImportTypeMap = %v, want %v", loader, info.ImportTypeMap, wantImportTypeMap)
+		}
+		wantFilePaths := []string{"native/native.go"}
+		if !reflect.DeepEqual(info.FilePaths, wantFilePaths) {
+			t.Errorf("loadPackageInfoMap() with the %s loader FilePaths = %v, want %v", loader, info.FilePaths, wantFilePaths)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package prelviz
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (m *Prelviz) writeMermaid(cycles [][]string) error {
+	_, err := fmt.Fprint(m.output, m.mermaid(cycles))
+	return err
+}
+
+// mermaid returns the package relation graph as a mermaid flowchart. Grouping nodes, modules of a workspace and the standard library are subgraphs.
+func (m *Prelviz) mermaid(cycles [][]string) string {
+	cyclicNodeMap := cyclicNodeMap(cycles)
+	nodeInfoMap := m.nodeInfoMap()
+	nodeNames := sortedKeys(nodeInfoMap)
+	idMap := make(map[string]string, len(nodeNames))
+	for i, nodeName := range nodeNames {
+		idMap[nodeName] = fmt.Sprintf("n%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart TB\n")
+	b.WriteString("    classDef package fill:#3288bd,color:#ffffbf\n")
+	b.WriteString("    classDef grouping fill:#66c2a5,color:#ffffbf\n")
+	b.WriteString("    classDef cyclic stroke:orange,stroke-width:3px\n")
+	if m.hasExternalNode(nodeInfoMap) {
+		fmt.Fprintf(&b, "    classDef external fill:%s,color:#ffffbf\n", externalFillColor)
+	}
+	groupingMemberMap := m.groupingMemberMap()
+	writeNode := func(nodeName string) {
+		info := nodeInfoMap[nodeName]
+		id := idMap[nodeName]
+		if info.IsExternal {
+			fmt.Fprintf(&b, "    %s[\"external: %s<br/>pkg: %d%s\"]\n", id, info.Name, info.ContainsPackageNum, m.mermaidLayerLabel(info))
+			fmt.Fprintf(&b, "    class %s external\n", id)
+		} else if info.IsGrouping {
+			// the packages under the directory are drawn in the subgraph, and the edges of the grouping node are linked to the subgraph.
+			fmt.Fprintf(&b, "    subgraph %s[\"path: %s<br/>pkg: %d%s%s\"]\n", id, info.DirectoryPath, info.ContainsPackageNum, m.mermaidLayerLabel(info), m.mermaidStdlibLabel(info))
+			for i, member := range groupingMemberMap[nodeName] {
+				fmt.Fprintf(&b, "        %s_%d[\"pkg: %s<br/>path: %s\"]\n", id, i, member.Name, member.DirectoryPath)
+				fmt.Fprintf(&b, "        class %s_%d package\n", id, i)
+			}
+			b.WriteString("    end\n")
+			fmt.Fprintf(&b, "    class %s grouping\n", id)
+		} else {
+			fmt.Fprintf(&b, "    %s[\"pkg: %s<br/>path: %s%s%s\"]\n", id, info.Name, info.DirectoryPath, m.mermaidLayerLabel(info), m.mermaidStdlibLabel(info))
+			fmt.Fprintf(&b, "    class %s package\n", id)
+		}
+		if _, ok := cyclicNodeMap[nodeName]; ok {
+			fmt.Fprintf(&b, "    class %s cyclic\n", id)
+		}
+	}
+	// nodes of a workspace are in the subgraph of their module, and nodes of the standard library are in their own subgraph.
+	moduleNodeNamesMap := make(map[string][]string)
+	stdlibNodeNames := make([]string, 0)
+	for _, nodeName := range nodeNames {
+		info := nodeInfoMap[nodeName]
+		switch {
+		case info.IsStdlib:
+			stdlibNodeNames = append(stdlibNodeNames, nodeName)
+		case info.Module != "":
+			moduleNodeNamesMap[info.Module] = append(moduleNodeNamesMap[info.Module], nodeName)
+		default:
+			writeNode(nodeName)
+		}
+	}
+	for i, module := range m.moduleWorkspace().Modules {
+		if len(moduleNodeNamesMap[module.Path]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "    subgraph m%d[\"module: %s\"]\n", i, module.Path)
+		for _, nodeName := range moduleNodeNamesMap[module.Path] {
+			writeNode(nodeName)
+		}
+		b.WriteString("    end\n")
+	}
+	if len(stdlibNodeNames) > 0 {
+		fmt.Fprintf(&b, "    subgraph std[\"%s\"]\n", stdlibGroupLabel)
+		for _, nodeName := range stdlibNodeNames {
+			writeNode(nodeName)
+		}
+		b.WriteString("    end\n")
+	}
+
+	relationCountMap := m.nodeRelationCountMap()
+	testOnlyRelationMap := m.nodeTestOnlyRelationMap()
+	linkIndex := 0
+	for _, srcNodeName := range sortedKeys(relationCountMap) {
+		for _, dstNodeName := range sortedKeys(relationCountMap[srcNodeName]) {
+			importTypes := m.nodeImportTypes(srcNodeName, dstNodeName)
+			arrow := "-->"
+			switch {
+			case isTestOnlyRelation(testOnlyRelationMap, srcNodeName, dstNodeName), isBlankImportOnly(importTypes):
+				arrow = "-.->"
+			case hasUnqualifiedImport(importTypes):
+				arrow = "==>"
+			}
+			// the label is quoted, because parentheses of the import types are the syntax of node shapes.
+			fmt.Fprintf(&b, "    %s %s|\"%s\"| %s\n", idMap[srcNodeName], arrow, m.relationLabel(srcNodeName, dstNodeName, relationCountMap[srcNodeName][dstNodeName], importTypes), idMap[dstNodeName])
+			if m.isViolation(srcNodeName, dstNodeName) {
+				fmt.Fprintf(&b, "    linkStyle %d stroke:red,color:red\n", linkIndex)
+			} else if m.isCyclicRelation(cyclicNodeMap, srcNodeName, dstNodeName) {
+				fmt.Fprintf(&b, "    linkStyle %d stroke:orange,color:orange\n", linkIndex)
+			}
+			linkIndex++
+		}
+	}
+	return b.String()
+}
+
+func (m *Prelviz) mermaidLayerLabel(info *NodeInfo) string {
+	if info.Layer == "" {
+		return ""
+	}
+	return fmt.Sprintf("<br/>layer: %s", info.Layer)
+}
+
+func (m *Prelviz) mermaidStdlibLabel(info *NodeInfo) string {
+	lines := stdlibUsageLines(info)
+	if len(lines) == 0 {
+		return ""
+	}
+	return "<br/>stdlib:<br/>" + strings.Join(lines, "<br/>")
+}
+
+// groupingMemberMap returns the packages in each grouping node sorted by the directory path.
+func (m *Prelviz) groupingMemberMap() map[string][]*PackageInfo {
+	groupingMemberMap := make(map[string][]*PackageInfo)
+	for _, pkgDirPath := range sortedKeys(m.packageInfoMap) {
+		if m.isExcludePackageWithDirPath(pkgDirPath) || !m.isGroupingNode(pkgDirPath) {
+			continue
+		}
+		nodeName := m.nodeName(pkgDirPath)
+		groupingMemberMap[nodeName] = append(groupingMemberMap[nodeName], m.packageInfoMap[pkgDirPath])
+	}
+	return groupingMemberMap
+}
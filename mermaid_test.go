@@ -0,0 +1,66 @@
+package prelviz
+
+import "testing"
+
+func TestPrelviz_mermaid(t *testing.T) {
+	m := newSamplePrelviz()
+	want := `flowchart TB
+    classDef package fill:#3288bd,color:#ffffbf
+    classDef grouping fill:#66c2a5,color:#ffffbf
+    classDef cyclic stroke:orange,stroke-width:3px
+    n0["pkg: dst2<br/>path: sample/dst2"]
+    class n0 package
+    subgraph n1["path: sample/grouping<br/>pkg: 1"]
+        n1_0["pkg: dst1<br/>path: sample/grouping/dst1"]
+        class n1_0 package
+    end
+    class n1 grouping
+    n2["pkg: src<br/>path: sample/src"]
+    class n2 package
+    n2 -->|"dep:1"| n0
+    n2 -->|"dep:2"| n1
+    linkStyle 1 stroke:red,color:red
+`
+	if got := m.mermaid(nil); got != want {
+		t.Errorf("Prelviz.mermaid() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPrelviz_mermaid_blankImport(t *testing.T) {
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"app": {
+				Name:          "app",
+				DirectoryPath: "app",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/driver": {},
+				},
+				ImportTypeMap: map[string]map[string]struct{}{
+					"mod/driver": {ImportTypeBlank: {}},
+				},
+			},
+			"driver": {
+				Name:           "driver",
+				DirectoryPath:  "driver",
+				ImportUsageMap: map[string]map[string]struct{}{},
+			},
+		},
+		config: &Config{
+			ExcludePackageMap: make(map[string]struct{}),
+		},
+	}
+	want := `flowchart TB
+    classDef package fill:#3288bd,color:#ffffbf
+    classDef grouping fill:#66c2a5,color:#ffffbf
+    classDef cyclic stroke:orange,stroke-width:3px
+    n0["pkg: app<br/>path: app"]
+    class n0 package
+    n1["pkg: driver<br/>path: driver"]
+    class n1 package
+    n0 -.->|"dep:0 (blank)"| n1
+`
+	if got := m.mermaid(nil); got != want {
+		t.Errorf("Prelviz.mermaid() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -1,29 +1,282 @@
 package prelviz
 
 import (
-	"bufio"
 	"errors"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/samber/lo"
+	"golang.org/x/mod/modfile"
 )
 
+// ModuleFile is the metadata in go.mod.
+type ModuleFile struct {
+	Path      string
+	GoVersion string
+	Requires  []*ModuleRequire
+	Replaces  []*ModuleReplace
+	Retracts  []*ModuleRetract
+}
+
+// ModuleRequire is a require directive. Indirect is true for the requirement with the // indirect comment.
+type ModuleRequire struct {
+	Path     string
+	Version  string
+	Indirect bool
+}
+
+// ModuleReplace is a replace directive. OldVersion is empty when every version is replaced,
+// and NewVersion is empty when the replacement is a directory.
+type ModuleReplace struct {
+	OldPath    string
+	OldVersion string
+	NewPath    string
+	NewVersion string
+}
+
+// ModuleRetract is a retract directive of the versions from Low to High.
+type ModuleRetract struct {
+	Low       string
+	High      string
+	Rationale string
+}
+
+// NewModuleFile parses go.mod in the directory.
+func NewModuleFile(rootPath string) (*ModuleFile, error) {
+	filePath := filepath.Join(rootPath, "go.mod")
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := modfile.Parse(filePath, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.Module == nil {
+		return nil, fmt.Errorf("%s: no module directive", filePath)
+	}
+
+	moduleFile := &ModuleFile{
+		Path: f.Module.Mod.Path,
+		Requires: lo.Map(f.Require, func(r *modfile.Require, _ int) *ModuleRequire {
+			return &ModuleRequire{Path: r.Mod.Path, Version: r.Mod.Version, Indirect: r.Indirect}
+		}),
+		Replaces: lo.Map(f.Replace, func(r *modfile.Replace, _ int) *ModuleReplace {
+			return &ModuleReplace{OldPath: r.Old.Path, OldVersion: r.Old.Version, NewPath: r.New.Path, NewVersion: r.New.Version}
+		}),
+		Retracts: lo.Map(f.Retract, func(r *modfile.Retract, _ int) *ModuleRetract {
+			return &ModuleRetract{Low: r.Low, High: r.High, Rationale: r.Rationale}
+		}),
+	}
+	if f.Go != nil {
+		moduleFile.GoVersion = f.Go.Version
+	}
+	return moduleFile, nil
+}
+
+// GetModuleName returns the module path in go.mod in the directory.
 func GetModuleName(rootPath string) (string, error) {
-	f, err := os.Open(filepath.Join(rootPath, "go.mod"))
+	moduleFile, err := NewModuleFile(rootPath)
 	if err != nil {
 		return "", err
 	}
-	defer f.Close()
+	return moduleFile.Path, nil
+}
 
-	scanner := bufio.NewScanner(f)
-	scanner.Scan()
-	if err = scanner.Err(); err != nil {
-		return "", err
+// Module is a module of the project. DirectoryPath is relative to the project directory.
+type Module struct {
+	Path          string
+	DirectoryPath string
+}
+
+// Workspace is the modules of the project. They are the modules used in go.work at the project directory,
+// or the module of go.mod at the project directory.
+type Workspace struct {
+	Modules []*Module
+	// RequiredModulePaths is the modules required by the go.mod of the modules, except the modules of the workspace.
+	RequiredModulePaths []string
+}
+
+const workFileName = "go.work"
+
+func NewWorkspace(projectDirectoryPath string) (*Workspace, error) {
+	workFilePath := filepath.Join(projectDirectoryPath, workFileName)
+	if !fileExists(workFilePath) {
+		moduleFile, err := NewModuleFile(projectDirectoryPath)
+		if err != nil {
+			return nil, err
+		}
+		workspace := NewModuleWorkspace(moduleFile.Path)
+		workspace.addRequiredModulePaths(moduleFile)
+		return workspace, nil
 	}
-	oneline := scanner.Text()
-	moduleInfo := strings.Split(oneline, " ")
-	if len(moduleInfo) != 2 {
-		return "", errors.New("invalid go.mod")
+
+	raw, err := os.ReadFile(workFilePath)
+	if err != nil {
+		return nil, err
+	}
+	work, err := modfile.ParseWork(workFilePath, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+	modules := make([]*Module, 0, len(work.Use))
+	moduleFiles := make([]*ModuleFile, 0, len(work.Use))
+	for _, use := range work.Use {
+		dirPath := filepath.Clean(filepath.FromSlash(use.Path))
+		if filepath.IsAbs(dirPath) {
+			absProjectDirectoryPath, err := filepath.Abs(projectDirectoryPath)
+			if err != nil {
+				return nil, err
+			}
+			if dirPath, err = filepath.Rel(absProjectDirectoryPath, dirPath); err != nil {
+				return nil, err
+			}
+		}
+		if dirPath == ".." || strings.HasPrefix(dirPath, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("module out of the project is not supported: %s", use.Path)
+		}
+		moduleFile, err := NewModuleFile(filepath.Join(projectDirectoryPath, dirPath))
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, &Module{Path: moduleFile.Path, DirectoryPath: filepath.ToSlash(dirPath)})
+		moduleFiles = append(moduleFiles, moduleFile)
+	}
+	if len(modules) == 0 {
+		return nil, errors.New("no module is used in go.work")
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].DirectoryPath < modules[j].DirectoryPath
+	})
+	workspace := &Workspace{Modules: modules}
+	for _, moduleFile := range moduleFiles {
+		workspace.addRequiredModulePaths(moduleFile)
+	}
+	return workspace, nil
+}
+
+// addRequiredModulePaths adds the modules required by go.mod which are out of the workspace.
+func (w *Workspace) addRequiredModulePaths(moduleFile *ModuleFile) {
+	for _, require := range moduleFile.Requires {
+		if lo.ContainsBy(w.Modules, func(module *Module) bool { return module.Path == require.Path }) {
+			continue
+		}
+		if lo.Contains(w.RequiredModulePaths, require.Path) {
+			continue
+		}
+		w.RequiredModulePaths = append(w.RequiredModulePaths, require.Path)
+	}
+	sort.Strings(w.RequiredModulePaths)
+}
+
+// NewModuleWorkspace returns the workspace of the single module at the project directory.
+func NewModuleWorkspace(moduleName string) *Workspace {
+	return &Workspace{Modules: []*Module{{Path: moduleName, DirectoryPath: "."}}}
+}
+
+// IsMultiModule reports whether the workspace has more than one module.
+func (w *Workspace) IsMultiModule() bool {
+	return len(w.Modules) > 1
+}
+
+// ProjectModulePath returns the path of the module of the project. It is empty for a workspace of multiple modules,
+// since none of them stands for the project.
+func (w *Workspace) ProjectModulePath() string {
+	if w.IsMultiModule() {
+		return ""
+	}
+	return w.Modules[0].Path
+}
+
+// ModulePaths returns the paths of the modules in the order of their directories.
+func (w *Workspace) ModulePaths() []string {
+	return lo.Map(w.Modules, func(module *Module, _ int) string {
+		return module.Path
+	})
+}
+
+// DirectoryModule returns the module which the directory belongs to. Nested modules are preferred to their parents.
+func (w *Workspace) DirectoryModule(dirPath string) (*Module, bool) {
+	dirPath = cleanDirectoryPath(dirPath)
+	var found *Module
+	for _, module := range w.Modules {
+		if !hasPathPrefix(dirPath, module.DirectoryPath) {
+			continue
+		}
+		if found == nil || len(module.DirectoryPath) > len(found.DirectoryPath) || found.DirectoryPath == "." {
+			found = module
+		}
+	}
+	return found, found != nil
+}
+
+// PackageModule returns the module which the package belongs to. Nested modules are preferred to their parents.
+func (w *Workspace) PackageModule(pkg string) (*Module, bool) {
+	var found *Module
+	for _, module := range w.Modules {
+		if !hasPathPrefix(pkg, module.Path) {
+			continue
+		}
+		if found == nil || len(module.Path) > len(found.Path) {
+			found = module
+		}
+	}
+	return found, found != nil
+}
+
+// RequiredModulePath returns the required module which the package belongs to. Nested modules are preferred to their parents.
+func (w *Workspace) RequiredModulePath(pkg string) (string, bool) {
+	found := ""
+	for _, modulePath := range w.RequiredModulePaths {
+		if hasPathPrefix(pkg, modulePath) && len(modulePath) > len(found) {
+			found = modulePath
+		}
+	}
+	return found, found != ""
+}
+
+// PackagePath returns the package path of the directory. A directory out of the modules is returned as it is.
+func (w *Workspace) PackagePath(dirPath string) string {
+	dirPath = cleanDirectoryPath(dirPath)
+	module, ok := w.DirectoryModule(dirPath)
+	if !ok {
+		return dirPath
+	}
+	return path.Join(module.Path, trimPathPrefix(dirPath, module.DirectoryPath))
+}
+
+// PackageDirectoryPath returns the directory of the package. ok is false for a package out of the modules.
+func (w *Workspace) PackageDirectoryPath(pkg string) (string, bool) {
+	module, ok := w.PackageModule(pkg)
+	if !ok {
+		return "", false
+	}
+	return path.Join(module.DirectoryPath, trimPathPrefix(pkg, module.Path)), true
+}
+
+// DirectoryPaths returns the directories of the modules.
+func (w *Workspace) DirectoryPaths() []string {
+	return lo.Map(w.Modules, func(module *Module, _ int) string {
+		return module.DirectoryPath
+	})
+}
+
+func cleanDirectoryPath(dirPath string) string {
+	return path.Clean(strings.TrimPrefix(filepath.ToSlash(dirPath), "/"))
+}
+
+// hasPathPrefix reports whether s is prefix or under prefix. "." is the prefix of every path.
+func hasPathPrefix(s, prefix string) bool {
+	return prefix == "." || s == prefix || strings.HasPrefix(s, prefix+"/")
+}
+
+func trimPathPrefix(s, prefix string) string {
+	if prefix == "." {
+		return s
 	}
-	return moduleInfo[1], nil
+	return strings.TrimPrefix(strings.TrimPrefix(s, prefix), "/")
 }
@@ -1,6 +1,9 @@
 package prelviz
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestGetModuleName(t *testing.T) {
 	type args struct {
@@ -36,6 +39,22 @@ func TestGetModuleName(t *testing.T) {
 			want:    "sample",
 			wantErr: false,
 		},
+		{
+			name: "normal: module directive after a blank line and a comment",
+			args: args{
+				rootPath: "testdata/module_test/comment",
+			},
+			want:    "sample/comment",
+			wantErr: false,
+		},
+		{
+			name: "normal: quoted module path",
+			args: args{
+				rootPath: "testdata/module_test/full",
+			},
+			want:    "example.com/full",
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -50,3 +69,222 @@ func TestGetModuleName(t *testing.T) {
 		})
 	}
 }
+
+func TestNewModuleFile(t *testing.T) {
+	type args struct {
+		rootPath string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *ModuleFile
+		wantErr bool
+	}{
+		{
+			name: "anomaly: go.mod is invalid format",
+			args: args{
+				rootPath: "testdata/module_test/invalid",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "normal: only module directive",
+			args: args{
+				rootPath: "testdata/module_test/valid",
+			},
+			want: &ModuleFile{
+				Path:     "sample",
+				Requires: []*ModuleRequire{},
+				Replaces: []*ModuleReplace{},
+				Retracts: []*ModuleRetract{},
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: every directive",
+			args: args{
+				rootPath: "testdata/module_test/full",
+			},
+			want: &ModuleFile{
+				Path:      "example.com/full",
+				GoVersion: "1.22",
+				Requires: []*ModuleRequire{
+					{Path: "example.com/dep", Version: "v1.2.0"},
+					{Path: "example.com/indirect", Version: "v0.1.0", Indirect: true},
+				},
+				Replaces: []*ModuleReplace{
+					{OldPath: "example.com/dep", OldVersion: "v1.2.0", NewPath: "../dep"},
+				},
+				Retracts: []*ModuleRetract{
+					{Low: "v1.0.0", High: "v1.0.5", Rationale: "published with a broken api"},
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewModuleFile(tt.args.rootPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewModuleFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewModuleFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWorkspace(t *testing.T) {
+	type args struct {
+		projectDirectoryPath string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *Workspace
+		wantErr bool
+	}{
+		{
+			name: "anomaly: neither go.work nor go.mod exists",
+			args: args{
+				projectDirectoryPath: "testdata",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "normal: go.mod",
+			args: args{
+				projectDirectoryPath: "testdata/module_test/valid",
+			},
+			want: &Workspace{
+				Modules: []*Module{{Path: "sample", DirectoryPath: "."}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: go.mod with requires",
+			args: args{
+				projectDirectoryPath: "testdata/module_test/full",
+			},
+			want: &Workspace{
+				Modules:             []*Module{{Path: "example.com/full", DirectoryPath: "."}},
+				RequiredModulePaths: []string{"example.com/dep", "example.com/indirect"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: go.work whose module requires another module of the workspace",
+			args: args{
+				projectDirectoryPath: "testdata/module_test/workspace",
+			},
+			want: &Workspace{
+				Modules: []*Module{
+					{Path: "example.com/a", DirectoryPath: "svc/a"},
+					{Path: "example.com/b", DirectoryPath: "svc/b"},
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewWorkspace(tt.args.projectDirectoryPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWorkspace() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewWorkspace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspace_PackagePath(t *testing.T) {
+	workspace := &Workspace{
+		Modules: []*Module{
+			{Path: "example.com/root", DirectoryPath: "."},
+			{Path: "example.com/a", DirectoryPath: "svc/a"},
+			{Path: "example.com/ab", DirectoryPath: "svc/ab"},
+		},
+	}
+	tests := []struct {
+		name    string
+		dirPath string
+		want    string
+	}{
+		{
+			name:    "normal: root of the parent module",
+			dirPath: ".",
+			want:    "example.com/root",
+		},
+		{
+			name:    "normal: package of the parent module",
+			dirPath: "svc/c",
+			want:    "example.com/root/svc/c",
+		},
+		{
+			name:    "normal: root of the nested module",
+			dirPath: "svc/a",
+			want:    "example.com/a",
+		},
+		{
+			name:    "normal: package of the nested module with a leading slash",
+			dirPath: "/svc/ab/domain",
+			want:    "example.com/ab/domain",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workspace.PackagePath(tt.dirPath); got != tt.want {
+				t.Errorf("Workspace.PackagePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspace_PackageDirectoryPath(t *testing.T) {
+	workspace := &Workspace{
+		Modules: []*Module{
+			{Path: "example.com/a", DirectoryPath: "svc/a"},
+			{Path: "example.com/ab", DirectoryPath: "svc/ab"},
+		},
+	}
+	tests := []struct {
+		name   string
+		pkg    string
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "normal: root of the module",
+			pkg:    "example.com/a",
+			want:   "svc/a",
+			wantOk: true,
+		},
+		{
+			name:   "normal: package of the module whose path is prefixed by another module",
+			pkg:    "example.com/ab/domain",
+			want:   "svc/ab/domain",
+			wantOk: true,
+		},
+		{
+			name:   "normal: package out of the modules",
+			pkg:    "example.com/abc",
+			want:   "",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := workspace.PackageDirectoryPath(tt.pkg)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Workspace.PackageDirectoryPath() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
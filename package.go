@@ -1,45 +1,154 @@
 package prelviz
 
 import (
+	"errors"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"github.com/samber/lo"
 )
 
 type PackageInfo struct {
-	Name           string
-	DirectoryPath  string
-	ImportUsageMap map[string]map[string]struct{}
+	Name              string
+	DirectoryPath     string
+	ImportUsageMap    map[string]map[string]struct{}
+	ImportPositionMap map[string][]Position
+	UsagePositionMap  map[string]map[string][]Position
+	// FilePaths is the go files of the package. They are relative to the project directory.
+	FilePaths []string
+	// TestImportUsageMap is the usages in the _test.go files of the package itself.
+	TestImportUsageMap map[string]map[string]struct{}
+	// IsTest is true for an external test package(package xxx_test). It is keyed by the directory path with the _test suffix.
+	IsTest bool
+	// ImportTypeMap is the types of the imports of each imported package. ex) normal, blank, dot, cgo
+	ImportTypeMap map[string]map[string]struct{}
+	// UsageKindMap is the kind of each identifier used from each imported package. ex) type, interface, func, const, var
+	// It is set only by the packages loader, since the kinds need type information.
+	UsageKindMap map[string]map[string]string
+	// SymbolKindMap is the kind of each exported type and function declared in the package. ex) type, interface, func
+	// SymbolUsageMap is the identifiers which each of them uses from each package. The import path is empty for the package itself.
+	// They are collected only from the files except tests for the type level graph.
+	SymbolKindMap  map[string]string
+	SymbolUsageMap map[string]map[string]map[string]struct{}
+}
+
+// testPackageSuffix is the suffix of the name and the key of an external test package.
+const testPackageSuffix = "_test"
+
+// packageKey returns the key of the package info in the package info map.
+func (p *PackageInfo) packageKey() string {
+	if p.IsTest {
+		return p.DirectoryPath + testPackageSuffix
+	}
+	return p.DirectoryPath
+}
+
+// Position is the location of an import spec or a selector usage. FilePath is relative to the project directory.
+// Func is the function which encloses the usage, such as "Run" or "Server.Run" for a method. It is empty out of functions.
+type Position struct {
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Func     string `json:"func,omitempty"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d", p.FilePath, p.Line)
 }
 
 type PackageInfoMap map[string]*PackageInfo
 
-func NewPackageInfoMap(projectDirectoryPath string) (map[string]*PackageInfo, error) {
-	filePaths, err := targetGoFilePaths(projectDirectoryPath)
+// NewPackageInfoMap parses the go files in the project which are selected by the load option.
+func NewPackageInfoMap(projectDirectoryPath string, loadOption *LoadOption) (map[string]*PackageInfo, error) {
+	return newPackageInfoMapOfDirectories(projectDirectoryPath, []string{"."}, loadOption)
+}
+
+// newPackageInfoMapOfDirectories parses the go files under the directories which are selected by the load option.
+// The directories are relative to the project directory, such as the modules of a workspace.
+func newPackageInfoMapOfDirectories(projectDirectoryPath string, dirPaths []string, loadOption *LoadOption) (map[string]*PackageInfo, error) {
+	filePaths, err := goFilePathsOfDirectories(projectDirectoryPath, dirPaths, loadOption)
 	if err != nil {
 		return nil, err
 	}
 
-	packageInfoMap := make(map[string]*PackageInfo)
+	fileInfos := make([]*PackageInfo, 0, len(filePaths))
 	for _, filePath := range filePaths {
 		var packageInfo *PackageInfo
 		packageInfo, err = NewPackageInfo(filePath, projectDirectoryPath)
 		if err != nil {
 			return nil, err
 		}
+		fileInfos = append(fileInfos, packageInfo)
+	}
+	return mergePackageInfos(fileInfos), nil
+}
 
-		if info, ok := packageInfoMap[packageInfo.DirectoryPath]; ok {
-			info.ImportUsageMap = lo.Assign(info.ImportUsageMap, packageInfo.ImportUsageMap)
-		} else {
-			packageInfoMap[packageInfo.DirectoryPath] = packageInfo
+// mergePackageInfos merges the package infos of go files into the package info of each directory.
+// The package infos of the files are not modified, so they can be cached and merged again.
+func mergePackageInfos(fileInfos []*PackageInfo) map[string]*PackageInfo {
+	packageInfoMap := make(map[string]*PackageInfo)
+	for _, packageInfo := range fileInfos {
+		info, ok := packageInfoMap[packageInfo.packageKey()]
+		if !ok {
+			info = &PackageInfo{
+				Name:              packageInfo.Name,
+				DirectoryPath:     packageInfo.DirectoryPath,
+				ImportUsageMap:    make(map[string]map[string]struct{}),
+				ImportPositionMap: make(map[string][]Position),
+				UsagePositionMap:  make(map[string]map[string][]Position),
+				IsTest:            packageInfo.IsTest,
+				ImportTypeMap:     make(map[string]map[string]struct{}),
+			}
+			packageInfoMap[packageInfo.packageKey()] = info
+		}
+
+		// identifiers of an import path are unioned, since the files of a package use different identifiers of it.
+		info.ImportUsageMap = unionSetMap(info.ImportUsageMap, packageInfo.ImportUsageMap)
+		if packageInfo.TestImportUsageMap != nil {
+			info.TestImportUsageMap = unionSetMap(info.TestImportUsageMap, packageInfo.TestImportUsageMap)
+		}
+		info.ImportTypeMap = unionSetMap(info.ImportTypeMap, packageInfo.ImportTypeMap)
+		for symbol, kind := range packageInfo.SymbolKindMap {
+			if info.SymbolKindMap == nil {
+				info.SymbolKindMap = make(map[string]string)
+			}
+			info.SymbolKindMap[symbol] = kind
+		}
+		for symbol, importUsageMap := range packageInfo.SymbolUsageMap {
+			if info.SymbolUsageMap == nil {
+				info.SymbolUsageMap = make(map[string]map[string]map[string]struct{})
+			}
+			info.SymbolUsageMap[symbol] = unionSetMap(info.SymbolUsageMap[symbol], importUsageMap)
+		}
+		for importPath, kindMap := range packageInfo.UsageKindMap {
+			if info.UsageKindMap == nil {
+				info.UsageKindMap = make(map[string]map[string]string)
+			}
+			if _, ok = info.UsageKindMap[importPath]; !ok {
+				info.UsageKindMap[importPath] = make(map[string]string)
+			}
+			for usage, kind := range kindMap {
+				info.UsageKindMap[importPath][usage] = kind
+			}
+		}
+		info.FilePaths = append(info.FilePaths, packageInfo.FilePaths...)
+		for importPath, positions := range packageInfo.ImportPositionMap {
+			info.ImportPositionMap[importPath] = append(info.ImportPositionMap[importPath], positions...)
+		}
+		for importPath, positionMap := range packageInfo.UsagePositionMap {
+			if _, ok = info.UsagePositionMap[importPath]; !ok {
+				info.UsagePositionMap[importPath] = make(map[string][]Position)
+			}
+			for usage, positions := range positionMap {
+				info.UsagePositionMap[importPath][usage] = append(info.UsagePositionMap[importPath][usage], positions...)
+			}
 		}
 	}
-	return packageInfoMap, nil
+	return packageInfoMap
 }
 
 func NewPackageInfo(filePath, projectDirectoryPath string) (*PackageInfo, error) {
@@ -49,16 +158,68 @@ func NewPackageInfo(filePath, projectDirectoryPath string) (*PackageInfo, error)
 		return nil, err
 	}
 
-	importUsageMap := make(map[string]map[string]struct{})
+	relativeFilePath, err := filepath.Rel(projectDirectoryPath, filePath)
+	if err != nil {
+		return nil, err
+	}
+
 	importUsageNameMap := make(map[string]string)
+	for _, spec := range f.Imports {
+		importPath := strings.Trim(spec.Path.Value, `"`)
+		if spec.Name != nil {
+			importUsageNameMap[spec.Name.Name] = importPath
+		} else {
+			importUsageNameMap[filepath.Base(importPath)] = importPath
+		}
+	}
+	return newFilePackageInfo(fset, f, relativeFilePath, func(ident *ast.Ident) (string, bool) {
+		importPath, ok := importUsageNameMap[ident.Name]
+		return importPath, ok
+	}, nil), nil
+}
+
+// newFilePackageInfo collects the imports of the go file and the selector usages of the imported packages.
+// importPathOf returns the import path of the package which the identifier refers to.
+// kindOf returns the kind of the selected identifier. It is nil when the kinds are unknown.
+func newFilePackageInfo(fset *token.FileSet, f *ast.File, relativeFilePath string, importPathOf func(ident *ast.Ident) (string, bool), kindOf func(sel *ast.Ident) string) *PackageInfo {
+	funcDecls := make([]*ast.FuncDecl, 0)
+	for _, decl := range f.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			funcDecls = append(funcDecls, funcDecl)
+		}
+	}
+	position := func(pos token.Pos) Position {
+		p := fset.Position(pos)
+		position := Position{FilePath: relativeFilePath, Line: p.Line, Column: p.Column}
+		for _, funcDecl := range funcDecls {
+			if funcDecl.Pos() <= pos && pos < funcDecl.End() {
+				position.Func = funcName(funcDecl)
+				break
+			}
+		}
+		return position
+	}
+
+	importUsageMap := make(map[string]map[string]struct{})
+	importPositionMap := make(map[string][]Position)
+	usagePositionMap := make(map[string]map[string][]Position)
+	importTypeMap := make(map[string]map[string]struct{})
+	var usageKindMap map[string]map[string]string
 	ast.Inspect(f, func(n ast.Node) bool {
 		switch x := n.(type) {
 		case *ast.ImportSpec:
 			importPath := strings.Trim(x.Path.Value, `"`)
-			if x.Name != nil {
-				importUsageNameMap[x.Name.Name] = importPath
-			} else {
-				importUsageNameMap[filepath.Base(importPath)] = importPath
+			importPositionMap[importPath] = append(importPositionMap[importPath], position(x.Pos()))
+			importType := importTypeOf(x)
+			if _, ok := importTypeMap[importPath]; !ok {
+				importTypeMap[importPath] = make(map[string]struct{})
+			}
+			importTypeMap[importPath][importType] = struct{}{}
+			if importType != ImportTypeNormal {
+				// blank and dot imports have no selector usages, but they are dependencies.
+				if _, ok := importUsageMap[importPath]; !ok {
+					importUsageMap[importPath] = make(map[string]struct{})
+				}
 			}
 		case *ast.SelectorExpr:
 			xIndent, ok := x.X.(*ast.Ident)
@@ -66,41 +227,161 @@ func NewPackageInfo(filePath, projectDirectoryPath string) (*PackageInfo, error)
 				return true
 			}
 			var importPath string
-			if importPath, ok = importUsageNameMap[xIndent.Name]; ok {
+			if importPath, ok = importPathOf(xIndent); ok {
 				if _, ok = importUsageMap[importPath]; ok {
 					importUsageMap[importPath][x.Sel.Name] = struct{}{}
 				} else {
 					importUsageMap[importPath] = map[string]struct{}{x.Sel.Name: {}}
 				}
+				if _, ok = usagePositionMap[importPath]; !ok {
+					usagePositionMap[importPath] = make(map[string][]Position)
+				}
+				usagePositionMap[importPath][x.Sel.Name] = append(usagePositionMap[importPath][x.Sel.Name], position(x.Pos()))
+				if kindOf == nil {
+					return true
+				}
+				if kind := kindOf(x.Sel); kind != "" {
+					if usageKindMap == nil {
+						usageKindMap = make(map[string]map[string]string)
+					}
+					if _, ok = usageKindMap[importPath]; !ok {
+						usageKindMap[importPath] = make(map[string]string)
+					}
+					usageKindMap[importPath][x.Sel.Name] = kind
+				}
 			}
 
 		}
 		return true
 	})
 
-	relativeFilePath, err := filepath.Rel(projectDirectoryPath, filePath)
-	if err != nil {
-		return nil, err
+	info := &PackageInfo{
+		Name:              f.Name.Name,
+		ImportUsageMap:    importUsageMap,
+		ImportPositionMap: importPositionMap,
+		UsagePositionMap:  usagePositionMap,
+		DirectoryPath:     filepath.Dir(relativeFilePath),
+		FilePaths:         []string{relativeFilePath},
+		ImportTypeMap:     importTypeMap,
+		UsageKindMap:      usageKindMap,
+	}
+	if !isTestFilePath(relativeFilePath) {
+		info.SymbolKindMap, info.SymbolUsageMap = collectSymbols(f, importPathOf)
+		return info
+	}
+	if strings.HasSuffix(info.Name, testPackageSuffix) {
+		info.IsTest = true
+	} else {
+		info.TestImportUsageMap = info.ImportUsageMap
+		info.ImportUsageMap = make(map[string]map[string]struct{})
+	}
+	return info
+}
+
+// funcName returns the name of the function, such as "Run", or "Server.Run" for a method of Server or *Server.
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	recv := decl.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
 	}
-	return &PackageInfo{
-		Name:           f.Name.Name,
-		ImportUsageMap: importUsageMap,
-		DirectoryPath:  filepath.Dir(relativeFilePath),
-	}, nil
+	switch x := recv.(type) {
+	case *ast.IndexExpr:
+		recv = x.X
+	case *ast.IndexListExpr:
+		recv = x.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + decl.Name.Name
+	}
+	return decl.Name.Name
+}
+
+func isTestFilePath(filePath string) bool {
+	return strings.HasSuffix(filePath, "_test.go")
+}
+
+// goFilePathsOfDirectories returns the go files under the directories selected by the load option.
+func goFilePathsOfDirectories(projectDirectoryPath string, dirPaths []string, loadOption *LoadOption) ([]string, error) {
+	filePaths := make([]string, 0)
+	for _, dirPath := range dirPaths {
+		moduleFilePaths, err := targetGoFilePaths(filepath.Join(projectDirectoryPath, dirPath), loadOption)
+		if err != nil {
+			return nil, err
+		}
+		filePaths = append(filePaths, moduleFilePaths...)
+	}
+	return filePaths, nil
 }
 
-func targetGoFilePaths(dir string) ([]string, error) {
+// targetGoFilePaths returns the go files in the directory selected by the load option.
+// Like the go command, it ignores vendor, testdata, directories and files beginning with _ or . and nested modules.
+// A directory which does not exist has no go files, but the other errors, such as permission denied, are returned.
+func targetGoFilePaths(dir string, loadOption *LoadOption) ([]string, error) {
 	filePaths := make([]string, 0)
 	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if strings.HasSuffix(path, "_test.go") {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && isIgnoredDirectory(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isTestFilePath(path) && !loadOption.includeTests() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || isIgnoredName(info.Name()) {
+			return nil
+		}
+		match, err := loadOption.buildOption().matchFile(path)
+		if err != nil {
+			return err
+		}
+		if !match {
 			return nil
 		}
-		if strings.HasSuffix(path, ".go") {
-			filePaths = append(filePaths, path)
+		if loadOption.skipGenerated() {
+			generated, err := isGeneratedFile(path)
+			if err != nil {
+				return err
+			}
+			if generated {
+				return nil
+			}
 		}
+		filePaths = append(filePaths, path)
 		return nil
 	}); err != nil {
 		return nil, err
 	}
 	return filePaths, nil
 }
+
+// isIgnoredDirectory reports whether the go command ignores the directory or it is the root of a nested module.
+func isIgnoredDirectory(dirPath string) bool {
+	name := filepath.Base(dirPath)
+	if name == "vendor" || name == "testdata" || isIgnoredName(name) {
+		return true
+	}
+	return fileExists(filepath.Join(dirPath, "go.mod"))
+}
+
+func isIgnoredName(name string) bool {
+	return strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".")
+}
+
+// isGeneratedFile reports whether the go file has the "// Code generated ... DO NOT EDIT." comment.
+func isGeneratedFile(filePath string) (bool, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), filePath, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return false, err
+	}
+	return ast.IsGenerated(f), nil
+}
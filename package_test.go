@@ -1,6 +1,13 @@
 package prelviz
 
 import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
@@ -49,7 +56,7 @@ func Test_NewPackageInfoMap(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewPackageInfoMap(tt.args.projectDirectoryPath)
+			got, err := NewPackageInfoMap(tt.args.projectDirectoryPath, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewPackageInfoMap() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -61,6 +68,51 @@ func Test_NewPackageInfoMap(t *testing.T) {
 	}
 }
 
+func Test_mergePackageInfos(t *testing.T) {
+	fileInfos := []*PackageInfo{
+		{
+			Name:              "app",
+			DirectoryPath:     "app",
+			ImportUsageMap:    map[string]map[string]struct{}{"fmt": {"Println": {}}, "os": {"Getenv": {}}},
+			ImportPositionMap: map[string][]Position{"fmt": {{FilePath: "app/a.go", Line: 3}}},
+			UsagePositionMap:  map[string]map[string][]Position{"fmt": {"Println": {{FilePath: "app/a.go", Line: 5}}}},
+			FilePaths:         []string{"app/a.go"},
+		},
+		{
+			Name:              "app",
+			DirectoryPath:     "app",
+			ImportUsageMap:    map[string]map[string]struct{}{"fmt": {"Printf": {}, "Println": {}}},
+			ImportPositionMap: map[string][]Position{"fmt": {{FilePath: "app/b.go", Line: 3}}},
+			UsagePositionMap:  map[string]map[string][]Position{"fmt": {"Println": {{FilePath: "app/b.go", Line: 7}}}},
+			FilePaths:         []string{"app/b.go"},
+		},
+	}
+	want := map[string]*PackageInfo{
+		"app": {
+			Name:              "app",
+			DirectoryPath:     "app",
+			ImportUsageMap:    map[string]map[string]struct{}{"fmt": {"Println": {}, "Printf": {}}, "os": {"Getenv": {}}},
+			ImportPositionMap: map[string][]Position{"fmt": {{FilePath: "app/a.go", Line: 3}, {FilePath: "app/b.go", Line: 3}}},
+			UsagePositionMap:  map[string]map[string][]Position{"fmt": {"Println": {{FilePath: "app/a.go", Line: 5}, {FilePath: "app/b.go", Line: 7}}}},
+			FilePaths:         []string{"app/a.go", "app/b.go"},
+			ImportTypeMap:     map[string]map[string]struct{}{},
+		},
+	}
+	// the result must not depend on the order of the files.
+	for _, infos := range [][]*PackageInfo{fileInfos, {fileInfos[1], fileInfos[0]}} {
+		got := mergePackageInfos(infos)
+		if !reflect.DeepEqual(got["app"].ImportUsageMap, want["app"].ImportUsageMap) {
+			t.Errorf("mergePackageInfos() ImportUsageMap = %v, want %v", got["app"].ImportUsageMap, want["app"].ImportUsageMap)
+		}
+	}
+	if got := mergePackageInfos(fileInfos); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergePackageInfos() = %v, want %v", got, want)
+	}
+	if len(fileInfos[0].ImportUsageMap["fmt"]) != 1 {
+		t.Errorf("mergePackageInfos() modifies the package info of a file")
+	}
+}
+
 func Test_NewPackageInfo(t *testing.T) {
 	type args struct {
 		filePath             string
@@ -85,6 +137,135 @@ func Test_NewPackageInfo(t *testing.T) {
 					"time": {"Time": {}, "DateOnly": {}},
 					"fmt":  {"Sprintf": {}},
 				},
+				ImportPositionMap: map[string][]Position{
+					"fmt":  {{FilePath: "nest/sample/sample.go", Line: 4, Column: 2}},
+					"time": {{FilePath: "nest/sample/sample.go", Line: 5, Column: 2}},
+				},
+				UsagePositionMap: map[string]map[string][]Position{
+					"time": {
+						"Time":     {{FilePath: "nest/sample/sample.go", Line: 12, Column: 18, Func: "NewSample"}},
+						"DateOnly": {{FilePath: "nest/sample/sample.go", Line: 14, Column: 44, Func: "NewSample"}},
+					},
+					"fmt": {
+						"Sprintf": {{FilePath: "nest/sample/sample.go", Line: 14, Column: 9, Func: "NewSample"}},
+					},
+				},
+				FilePaths: []string{"nest/sample/sample.go"},
+				ImportTypeMap: map[string]map[string]struct{}{
+					"fmt":  {ImportTypeNormal: {}},
+					"time": {ImportTypeNormal: {}},
+				},
+				SymbolKindMap: map[string]string{
+					"Sample":       IdentifierKindType,
+					"NewSample":    IdentifierKindFunc,
+					"SampleString": IdentifierKindFunc,
+				},
+				SymbolUsageMap: map[string]map[string]map[string]struct{}{
+					"Sample": {},
+					"NewSample": {
+						"":     {"Sample": {}},
+						"time": {"Time": {}, "DateOnly": {}},
+						"fmt":  {"Sprintf": {}},
+					},
+					"SampleString": {},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: test file of the package itself",
+			args: args{
+				filePath:             "testdata/package_test/tests/app/app_test.go",
+				projectDirectoryPath: "testdata/package_test/tests",
+			},
+			want: &PackageInfo{
+				Name:           "app",
+				DirectoryPath:  "app",
+				ImportUsageMap: map[string]map[string]struct{}{},
+				ImportPositionMap: map[string][]Position{
+					"sample/domain": {{FilePath: "app/app_test.go", Line: 3, Column: 8}},
+				},
+				UsagePositionMap: map[string]map[string][]Position{
+					"sample/domain": {
+						"Do": {{FilePath: "app/app_test.go", Line: 6, Column: 2, Func: "helper"}},
+					},
+				},
+				FilePaths: []string{"app/app_test.go"},
+				TestImportUsageMap: map[string]map[string]struct{}{
+					"sample/domain": {"Do": {}},
+				},
+				ImportTypeMap: map[string]map[string]struct{}{
+					"sample/domain": {ImportTypeNormal: {}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: external test package",
+			args: args{
+				filePath:             "testdata/package_test/tests/app/external_test.go",
+				projectDirectoryPath: "testdata/package_test/tests",
+			},
+			want: &PackageInfo{
+				Name:          "app_test",
+				DirectoryPath: "app",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"sample/app": {"Run": {}},
+				},
+				ImportPositionMap: map[string][]Position{
+					"sample/app": {{FilePath: "app/external_test.go", Line: 3, Column: 8}},
+				},
+				UsagePositionMap: map[string]map[string][]Position{
+					"sample/app": {
+						"Run": {{FilePath: "app/external_test.go", Line: 6, Column: 2, Func: "example"}},
+					},
+				},
+				FilePaths: []string{"app/external_test.go"},
+				IsTest:    true,
+				ImportTypeMap: map[string]map[string]struct{}{
+					"sample/app": {ImportTypeNormal: {}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: blank and dot imports are dependencies without usages",
+			args: args{
+				filePath:             "testdata/package_test/importtype/store/store.go",
+				projectDirectoryPath: "testdata/package_test/importtype",
+			},
+			want: &PackageInfo{
+				Name:          "store",
+				DirectoryPath: "store",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"fmt":               {"Sprint": {}},
+					"github.com/lib/pq": {},
+					"strings":           {},
+				},
+				ImportPositionMap: map[string][]Position{
+					"fmt":               {{FilePath: "store/store.go", Line: 4, Column: 2}},
+					"github.com/lib/pq": {{FilePath: "store/store.go", Line: 6, Column: 2}},
+					"strings":           {{FilePath: "store/store.go", Line: 7, Column: 2}},
+				},
+				UsagePositionMap: map[string]map[string][]Position{
+					"fmt": {
+						"Sprint": {{FilePath: "store/store.go", Line: 11, Column: 9, Func: "Name"}},
+					},
+				},
+				FilePaths: []string{"store/store.go"},
+				ImportTypeMap: map[string]map[string]struct{}{
+					"fmt":               {ImportTypeNormal: {}},
+					"github.com/lib/pq": {ImportTypeBlank: {}},
+					"strings":           {ImportTypeDot: {}},
+				},
+				SymbolKindMap: map[string]string{"Name": IdentifierKindFunc},
+				SymbolUsageMap: map[string]map[string]map[string]struct{}{
+					"Name": {
+						// identifiers of a dot import are taken as those of the package itself.
+						"":    {"ToUpper": {}},
+						"fmt": {"Sprint": {}},
+					},
+				},
 			},
 			wantErr: false,
 		},
@@ -105,7 +286,8 @@ func Test_NewPackageInfo(t *testing.T) {
 
 func Test_targetGoFilePaths(t *testing.T) {
 	type args struct {
-		dir string
+		dir        string
+		loadOption *LoadOption
 	}
 	tests := []struct {
 		name    string
@@ -142,10 +324,95 @@ func Test_targetGoFilePaths(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "normal: ignore directories like the go command",
+			args: args{
+				dir: "testdata/package_test/walk",
+			},
+			want: []string{
+				"testdata/package_test/walk/app/app.go",
+				"testdata/package_test/walk/gen/gen.pb.go",
+				"testdata/package_test/walk/gen/manual.go",
+				"testdata/package_test/walk/main.go",
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: skip generated files",
+			args: args{
+				dir:        "testdata/package_test/walk",
+				loadOption: &LoadOption{SkipGenerated: true},
+			},
+			want: []string{
+				"testdata/package_test/walk/app/app.go",
+				"testdata/package_test/walk/gen/manual.go",
+				"testdata/package_test/walk/main.go",
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: the root directory is not ignored",
+			args: args{
+				dir: "testdata/package_test/walk/nested",
+			},
+			want: []string{
+				"testdata/package_test/walk/nested/nested.go",
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: include tests",
+			args: args{
+				dir:        "testdata/package_test/tests",
+				loadOption: &LoadOption{IncludeTests: true},
+			},
+			want: []string{
+				"testdata/package_test/tests/app/app.go",
+				"testdata/package_test/tests/app/app_test.go",
+				"testdata/package_test/tests/app/external_test.go",
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: go files except the ignore tag without build option",
+			args: args{
+				dir: "testdata/package_test/generate",
+			},
+			want: []string{
+				"testdata/package_test/generate/generate.go",
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: linux amd64",
+			args: args{
+				dir:        "testdata/package_test/build",
+				loadOption: &LoadOption{Build: &BuildOption{GOOS: "linux", GOARCH: "amd64"}},
+			},
+			want: []string{
+				"testdata/package_test/build/common.go",
+				"testdata/package_test/build/os_linux.go",
+			},
+			wantErr: false,
+		},
+		{
+			name: "normal: windows arm64 with tags",
+			args: args{
+				dir:        "testdata/package_test/build",
+				loadOption: &LoadOption{Build: &BuildOption{Tags: []string{"integration"}, GOOS: "windows", GOARCH: "arm64"}},
+			},
+			want: []string{
+				"testdata/package_test/build/arch.go",
+				"testdata/package_test/build/common.go",
+				"testdata/package_test/build/integration.go",
+				"testdata/package_test/build/os_windows.go",
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := targetGoFilePaths(tt.args.dir)
+			got, err := targetGoFilePaths(tt.args.dir, tt.args.loadOption)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("targetGoFilePaths() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -158,3 +425,45 @@ func Test_targetGoFilePaths(t *testing.T) {
 		})
 	}
 }
+
+func Test_targetGoFilePaths_permission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read a directory without permission")
+	}
+	dir := t.TempDir()
+	denied := filepath.Join(dir, "denied")
+	if err := os.Mkdir(denied, 0o000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(denied, 0o755) })
+	if _, err := targetGoFilePaths(dir, nil); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("targetGoFilePaths() error = %v, want permission denied", err)
+	}
+}
+
+func Test_funcName(t *testing.T) {
+	src := `package sample
+
+func Run() {}
+
+func (s Server) Start() {}
+
+func (s *Server) Stop() {}
+
+func (l *List[T]) Len() int { return 0 }
+
+func (m Map[K, V]) Keys() {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "sample.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Run", "Server.Start", "Server.Stop", "List.Len", "Map.Keys"}
+	got := make([]string, 0, len(f.Decls))
+	for _, decl := range f.Decls {
+		got = append(got, funcName(decl.(*ast.FuncDecl)))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("funcName() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package prelviz
+
+import "strings"
+
+// matchPattern reports whether the package path matches the pattern.
+// "..." matches any string including "/", and "*" matches any string in one path element.
+// As with the go command, a trailing "/..." also matches the path without it.
+// A pattern without wildcards matches only the same path.
+func matchPattern(pattern, path string) bool {
+	if strings.HasSuffix(pattern, "/...") && matchWildcard(strings.TrimSuffix(pattern, "/..."), path) {
+		return true
+	}
+	return matchWildcard(pattern, path)
+}
+
+func matchWildcard(pattern, path string) bool {
+	for len(pattern) > 0 {
+		switch {
+		case strings.HasPrefix(pattern, "..."):
+			rest := pattern[len("..."):]
+			for i := 0; i <= len(path); i++ {
+				if matchWildcard(rest, path[i:]) {
+					return true
+				}
+			}
+			return false
+		case pattern[0] == '*':
+			rest := pattern[1:]
+			for i := 0; i <= len(path); i++ {
+				if matchWildcard(rest, path[i:]) {
+					return true
+				}
+				if i < len(path) && path[i] == '/' {
+					break
+				}
+			}
+			return false
+		default:
+			if len(path) == 0 || path[0] != pattern[0] {
+				return false
+			}
+			pattern, path = pattern[1:], path[1:]
+		}
+	}
+	return len(path) == 0
+}
+
+// matchNegatablePattern is matchPattern which inverts the result when the pattern starts with "!".
+func matchNegatablePattern(pattern, path string) bool {
+	if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+		return !matchPattern(negated, path)
+	}
+	return matchPattern(pattern, path)
+}
+
+// matchPatternSet reports whether the package path matches any pattern in the set and no negated pattern in it.
+// A set which has only negated patterns matches every path which none of them matches.
+func matchPatternSet(patternSet map[string]struct{}, path string) bool {
+	hasPositive, hasNegative, matched := false, false, false
+	for pattern := range patternSet {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchPattern(negated, path) {
+				return false
+			}
+			hasNegative = true
+			continue
+		}
+		hasPositive = true
+		if !matched && matchPattern(pattern, path) {
+			matched = true
+		}
+	}
+	return matched || (hasNegative && !hasPositive)
+}
@@ -0,0 +1,98 @@
+package prelviz
+
+import "testing"
+
+func Test_matchPattern(t *testing.T) {
+	type args struct {
+		pattern string
+		path    string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "normal: same path",
+			args: args{pattern: "mod/app/domain", path: "mod/app/domain"},
+			want: true,
+		},
+		{
+			name: "normal: different path",
+			args: args{pattern: "mod/app/domain", path: "mod/app/domain/model"},
+			want: false,
+		},
+		{
+			name: "normal: leading ... matches module path",
+			args: args{pattern: ".../app/infra/...", path: "github.com/sample/app/infra/tmpmemory"},
+			want: true,
+		},
+		{
+			name: "normal: trailing /... matches the path itself",
+			args: args{pattern: ".../app/infra/...", path: "github.com/sample/app/infra"},
+			want: true,
+		},
+		{
+			name: "normal: trailing /... do not match the other directory with same prefix",
+			args: args{pattern: ".../app/infra/...", path: "github.com/sample/app/infrastructure"},
+			want: false,
+		},
+		{
+			name: "normal: * matches one path element",
+			args: args{pattern: "mod/app/*/model", path: "mod/app/domain/model"},
+			want: true,
+		},
+		{
+			name: "normal: * do not match multiple path elements",
+			args: args{pattern: "mod/app/*/model", path: "mod/app/domain/sub/model"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPattern(tt.args.pattern, tt.args.path); got != tt.want {
+				t.Errorf("matchPattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_matchPatternSet(t *testing.T) {
+	type args struct {
+		patternSet map[string]struct{}
+		path       string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "normal: empty set",
+			args: args{patternSet: map[string]struct{}{}, path: "mod/app/infra"},
+			want: false,
+		},
+		{
+			name: "normal: positive pattern matches",
+			args: args{patternSet: map[string]struct{}{"mod/app/infra/...": {}}, path: "mod/app/infra/db"},
+			want: true,
+		},
+		{
+			name: "normal: negated pattern matches",
+			args: args{patternSet: map[string]struct{}{"mod/app/infra/...": {}, "!mod/app/infra/shared": {}}, path: "mod/app/infra/shared"},
+			want: false,
+		},
+		{
+			name: "normal: only negated pattern do not match",
+			args: args{patternSet: map[string]struct{}{"!mod/app/domain/...": {}}, path: "mod/app/infra"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPatternSet(tt.args.patternSet, tt.args.path); got != tt.want {
+				t.Errorf("matchPatternSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package prelviz
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (m *Prelviz) writePlantUML(cycles [][]string) error {
+	_, err := fmt.Fprint(m.output, m.plantUML(cycles))
+	return err
+}
+
+// plantUML returns the package relation graph as a PlantUML component diagram.
+// Packages are components, grouping nodes are package blocks and modules of a workspace and the standard library are frames.
+func (m *Prelviz) plantUML(cycles [][]string) string {
+	cyclicNodeMap := cyclicNodeMap(cycles)
+	nodeInfoMap := m.nodeInfoMap()
+	nodeNames := sortedKeys(nodeInfoMap)
+	idMap := make(map[string]string, len(nodeNames))
+	for i, nodeName := range nodeNames {
+		idMap[nodeName] = fmt.Sprintf("n%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	if m.hasExternalNode(nodeInfoMap) {
+		fmt.Fprintf(&b, "skinparam component {\n  BorderColor<<cyclic>> orange\n  BackgroundColor<<external>> %s\n}\n", externalFillColor)
+	} else {
+		b.WriteString("skinparam component {\n  BorderColor<<cyclic>> orange\n}\n")
+	}
+	b.WriteString("skinparam package {\n  BorderColor<<cyclic>> orange\n}\n")
+	writeNode := func(nodeName string) {
+		info := nodeInfoMap[nodeName]
+		stereotype := ""
+		if _, ok := cyclicNodeMap[nodeName]; ok {
+			stereotype = " <<cyclic>>"
+		}
+		if info.IsExternal {
+			fmt.Fprintf(&b, "component \"external: %s\\npkg: %d%s\" as %s <<external>>%s\n", info.Name, info.ContainsPackageNum, m.plantUMLLayerLabel(info), idMap[nodeName], stereotype)
+		} else if info.IsGrouping {
+			fmt.Fprintf(&b, "package \"path: %s\\npkg: %d%s%s\" as %s%s {\n}\n", info.DirectoryPath, info.ContainsPackageNum, m.plantUMLLayerLabel(info), m.plantUMLStdlibLabel(info), idMap[nodeName], stereotype)
+		} else {
+			fmt.Fprintf(&b, "component \"pkg: %s\\npath: %s%s%s\" as %s%s\n", info.Name, info.DirectoryPath, m.plantUMLLayerLabel(info), m.plantUMLStdlibLabel(info), idMap[nodeName], stereotype)
+		}
+	}
+	// nodes of a workspace are in the frame of their module, and nodes of the standard library are in their own frame.
+	moduleNodeNamesMap := make(map[string][]string)
+	stdlibNodeNames := make([]string, 0)
+	for _, nodeName := range nodeNames {
+		info := nodeInfoMap[nodeName]
+		switch {
+		case info.IsStdlib:
+			stdlibNodeNames = append(stdlibNodeNames, nodeName)
+		case info.Module != "":
+			moduleNodeNamesMap[info.Module] = append(moduleNodeNamesMap[info.Module], nodeName)
+		default:
+			writeNode(nodeName)
+		}
+	}
+	for _, module := range m.moduleWorkspace().Modules {
+		if len(moduleNodeNamesMap[module.Path]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "frame \"module: %s\" {\n", module.Path)
+		for _, nodeName := range moduleNodeNamesMap[module.Path] {
+			writeNode(nodeName)
+		}
+		b.WriteString("}\n")
+	}
+	if len(stdlibNodeNames) > 0 {
+		fmt.Fprintf(&b, "frame \"%s\" {\n", stdlibGroupLabel)
+		for _, nodeName := range stdlibNodeNames {
+			writeNode(nodeName)
+		}
+		b.WriteString("}\n")
+	}
+
+	relationCountMap := m.nodeRelationCountMap()
+	testOnlyRelationMap := m.nodeTestOnlyRelationMap()
+	for _, srcNodeName := range sortedKeys(relationCountMap) {
+		for _, dstNodeName := range sortedKeys(relationCountMap[srcNodeName]) {
+			relationNum := relationCountMap[srcNodeName][dstNodeName]
+			importTypes := m.nodeImportTypes(srcNodeName, dstNodeName)
+			styles := make([]string, 0)
+			stereotype := ""
+			switch {
+			case m.isViolation(srcNodeName, dstNodeName):
+				styles = append(styles, "#red")
+				stereotype = " <<violation>>"
+			case m.isCyclicRelation(cyclicNodeMap, srcNodeName, dstNodeName):
+				styles = append(styles, "#orange")
+				stereotype = " <<cyclic>>"
+			}
+			if isTestOnlyRelation(testOnlyRelationMap, srcNodeName, dstNodeName) {
+				styles = append(styles, "dashed")
+			} else if isBlankImportOnly(importTypes) {
+				styles = append(styles, "dotted")
+			}
+			if hasUnqualifiedImport(importTypes) {
+				styles = append(styles, "bold")
+			}
+			arrow := "-->"
+			if len(styles) > 0 {
+				arrow = fmt.Sprintf("-[%s]->", strings.Join(styles, ","))
+			}
+			fmt.Fprintf(&b, "%s %s %s : %s%s\n", idMap[srcNodeName], arrow, idMap[dstNodeName], m.relationLabel(srcNodeName, dstNodeName, relationNum, importTypes), stereotype)
+		}
+	}
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+func (m *Prelviz) plantUMLLayerLabel(info *NodeInfo) string {
+	if info.Layer == "" {
+		return ""
+	}
+	return fmt.Sprintf("\\nlayer: %s", info.Layer)
+}
+
+func (m *Prelviz) plantUMLStdlibLabel(info *NodeInfo) string {
+	lines := stdlibUsageLines(info)
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\\nstdlib:\\n" + strings.Join(lines, "\\n")
+}
@@ -0,0 +1,25 @@
+package prelviz
+
+import "testing"
+
+func TestPrelviz_plantUML(t *testing.T) {
+	m := newSamplePrelviz()
+	want := `@startuml
+skinparam component {
+  BorderColor<<cyclic>> orange
+}
+skinparam package {
+  BorderColor<<cyclic>> orange
+}
+component "pkg: dst2\npath: sample/dst2" as n0
+package "path: sample/grouping\npkg: 1" as n1 {
+}
+component "pkg: src\npath: sample/src" as n2
+n2 --> n0 : dep:1
+n2 -[#red]-> n1 : dep:2 <<violation>>
+@enduml
+`
+	if got := m.plantUML(nil); got != want {
+		t.Errorf("Prelviz.plantUML() =\n%s\nwant\n%s", got, want)
+	}
+}
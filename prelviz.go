@@ -7,16 +7,130 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/awalterschulze/gographviz"
 	"github.com/samber/lo"
 )
 
 type Prelviz struct {
+	// projectModuleName is the path of the module of the project. It is empty for a workspace of multiple modules.
 	projectModuleName string
-	packageInfoMap    map[string]*PackageInfo
-	config            *Config
-	output            io.Writer
-	dotLayout         string
+	// workspace is the modules of the project. If it is nil, the project is the single module named projectModuleName.
+	workspace      *Workspace
+	packageInfoMap map[string]*PackageInfo
+	config         *Config
+	output         io.Writer
+	errOutput      io.Writer
+	dotLayout      string
+	format         string
+	// external is the mode to draw the packages out of the project. ex) none, package, module
+	external string
+	// stdlib is the mode to draw the packages of the standard library. ex) none, node, annotation
+	stdlib string
+	// label is the count on the edges. ex) identifiers, references, files
+	label string
+	// level is the granularity of the nodes. ex) package, type
+	level string
+}
+
+// Option is the options of Prelviz.
+type Option struct {
+	// DotLayout is the layout engine of the dot format. ex) dot, neato, fdp, sfdp, twopi, circo
+	DotLayout string
+	// Format is the output format. ex) dot, json, mermaid, plantuml, svg, png, html
+	// If it is empty, the format is detected by the extension of the output file path and defaults to dot.
+	Format string
+	// Loader is the way to load packages. ex) ast, packages
+	// If it is empty, the ast loader is used.
+	Loader string
+	// Build is the build constraints which select go files. If it is nil, every go file is loaded.
+	Build *BuildOption
+	// IncludeTests includes _test.go files. External test packages(package xxx_test) are nodes with the _test suffix
+	// and dependencies only from tests are test-only relations.
+	IncludeTests bool
+	// SkipGenerated skips the files which have the "// Code generated ... DO NOT EDIT." comment.
+	SkipGenerated bool
+	// External is the mode to draw the imported packages out of the project. ex) none, package, module
+	// If it is empty, they are not drawn.
+	External string
+	// Stdlib is the mode to draw the imported packages of the standard library. ex) none, node, annotation
+	// If it is empty, they are drawn by External.
+	Stdlib string
+	// Label is the count on the edges. ex) identifiers, references, files
+	// If it is empty, the edges are labeled with the number of distinct identifiers.
+	Label string
+	// Level is the granularity of the nodes. ex) package, type
+	// If it is empty, the packages are nodes. The type level supports the dot, json and mermaid formats.
+	Level string
+}
+
+func (o *Option) loadOption() *LoadOption {
+	return &LoadOption{
+		Build:         o.Build,
+		IncludeTests:  o.IncludeTests,
+		SkipGenerated: o.SkipGenerated,
+	}
+}
+
+const (
+	// LabelIdentifiers labels an edge with the number of distinct identifiers used from the packages of the dst node.
+	LabelIdentifiers = "identifiers"
+	// LabelReferences labels an edge with the number of references to the packages of the dst node.
+	LabelReferences = "references"
+	// LabelFiles labels an edge with the number of files which import the packages of the dst node.
+	LabelFiles = "files"
+)
+
+// labelPrefixMap is the prefix of the count on the edges of each label.
+var labelPrefixMap = map[string]string{
+	LabelIdentifiers: "dep",
+	LabelReferences:  "ref",
+	LabelFiles:       "files",
+}
+
+// validateLabel returns an error for an unsupported label.
+func validateLabel(label string) error {
+	if _, ok := labelPrefixMap[label]; ok || label == "" {
+		return nil
+	}
+	return fmt.Errorf("unsupported label: %s", label)
+}
+
+// edgeLabel returns the label of an edge whose count is relationNum, such as "dep:3".
+func (m *Prelviz) edgeLabel(relationNum int) string {
+	prefix, ok := labelPrefixMap[m.label]
+	if !ok {
+		prefix = labelPrefixMap[LabelIdentifiers]
+	}
+	return fmt.Sprintf("%s:%d", prefix, relationNum)
+}
+
+// relationLabel returns the full label of the edge between the nodes, such as "dep:8 types:3 funcs:5 (dot)".
+// The breakdown of the identifiers by kind is added when the packages are loaded with type information.
+func (m *Prelviz) relationLabel(srcNodeName, dstNodeName string, relationNum int, importTypes []string) string {
+	label := m.edgeLabel(relationNum)
+	if kinds := identifierKindsLabel(identifierKindCounts(m.nodeUsageKindMap(srcNodeName, dstNodeName))); kinds != "" {
+		label += " " + kinds
+	}
+	return label + importTypesLabel(importTypes)
+}
+
+const (
+	FormatDot      = "dot"
+	FormatJSON     = "json"
+	FormatMermaid  = "mermaid"
+	FormatPlantUML = "plantuml"
+	FormatSVG      = "svg"
+	FormatPNG      = "png"
+	FormatHTML     = "html"
+)
+
+// formatExtensionMap is the formats detected by the extension of the output file path.
+var formatExtensionMap = map[string]string{
+	".json": FormatJSON,
+	".mmd":  FormatMermaid,
+	".puml": FormatPlantUML,
+	".svg":  FormatSVG,
+	".png":  FormatPNG,
+	".html": FormatHTML,
 }
 
 type NodeInfo struct {
@@ -24,24 +138,56 @@ type NodeInfo struct {
 	DirectoryPath      string
 	IsGrouping         bool
 	ContainsPackageNum int
+	Layer              string
+	// IsTest is true for an external test package.
+	IsTest bool
+	// Module is the path of the module which the node belongs to. It is set only for a workspace of multiple modules.
+	Module string
+	// IsExternal is true for a package or a module out of the project.
+	IsExternal bool
+	// IsStdlib is true for a package of the standard library.
+	IsStdlib bool
+	// StdlibUsageMap is the identifiers of the standard library which the node uses. It is set only in the stdlib annotation mode.
+	StdlibUsageMap map[string]map[string]struct{}
 }
 
-func NewPrelviz(projectDirectoryPath, outputFilePath, dotLayout string) (*Prelviz, error) {
-	moduleName, err := GetModuleName(projectDirectoryPath)
+func NewPrelviz(projectDirectoryPath, outputFilePath string, option *Option) (*Prelviz, error) {
+	if err := validateExternal(option.External); err != nil {
+		return nil, err
+	}
+	if err := validateStdlib(option.Stdlib); err != nil {
+		return nil, err
+	}
+	if err := validateLabel(option.Label); err != nil {
+		return nil, err
+	}
+	if err := validateLevel(option.Level); err != nil {
+		return nil, err
+	}
+
+	workspace, err := NewWorkspace(projectDirectoryPath)
 	if err != nil {
 		return nil, err
 	}
 
-	packageInfoMap, err := NewPackageInfoMap(projectDirectoryPath)
+	packageInfoMap, err := loadPackageInfoMap(projectDirectoryPath, workspace, option.Loader, option.loadOption(), os.Stderr)
 	if err != nil {
 		return nil, err
 	}
 
-	config, err := NewConfig(projectDirectoryPath, moduleName)
+	config, err := NewWorkspaceConfig(projectDirectoryPath, workspace)
 	if err != nil {
 		return nil, err
 	}
 
+	format := option.Format
+	if format == "" {
+		format = FormatDot
+		if f, ok := formatExtensionMap[strings.ToLower(filepath.Ext(outputFilePath))]; ok {
+			format = f
+		}
+	}
+
 	var output io.Writer
 	if outputFilePath == "" {
 		output = os.Stdout
@@ -53,109 +199,49 @@ func NewPrelviz(projectDirectoryPath, outputFilePath, dotLayout string) (*Prelvi
 	}
 
 	return &Prelviz{
-		projectModuleName: moduleName,
+		projectModuleName: workspace.ProjectModulePath(),
+		workspace:         workspace,
 		packageInfoMap:    packageInfoMap,
 		config:            config,
 		output:            output,
-		dotLayout:         dotLayout,
+		errOutput:         os.Stderr,
+		dotLayout:         option.DotLayout,
+		format:            format,
+		external:          option.External,
+		stdlib:            option.Stdlib,
+		label:             option.Label,
+		level:             option.Level,
 	}, nil
 }
 
 func (m *Prelviz) Run() error {
-	// add graph
-	var (
-		graphDefaultAttrs = map[string]string{
-			"charset":   `"UTF-8"`,
-			"label":     `"package relation"`,
-			"labelloc":  `"t"`,
-			"labeljust": `"c"`,
-			"bgcolor":   `"#343434"`,
-			"fontsize":  "18",
-			"fontcolor": `"white"`,
-			"style":     `"filled"`,
-			"rankdir":   `"TB"`,
-			"margin":    "0.5",
-			"layout":    fmt.Sprintf(`"%s"`, m.dotLayout),
-		}
-		nodeDefaultAttrs = map[string]string{
-			"shape":       `"record"`,
-			"style":       `"solid,filled"`,
-			"fontcolor":   "6",
-			"fontsize":    "14",
-			"color":       "7",
-			"colorscheme": `"spectral11"`,
-		}
-	)
-	graphAst, _ := gographviz.ParseString(`digraph d {}`)
-	graph := gographviz.NewGraph()
-	if err := gographviz.Analyse(graphAst, graph); err != nil {
-		return err
+	if m.level == LevelType {
+		return m.writeTypeGraph()
 	}
-	graphAttrs, err := gographviz.NewAttrs(graphDefaultAttrs)
-	if err != nil {
-		return err
-	}
-	graph.Attrs.Extend(graphAttrs)
-
-	// add node
-	for nodeName, info := range m.nodeInfoMap() {
-		if info.IsGrouping {
-			if graph.IsNode(nodeName) {
-				continue
-			}
-			if err = graph.AddNode("G", m.toDotLangFormat(nodeName), lo.Assign(
-				nodeDefaultAttrs,
-				map[string]string{
-					"fillcolor": "9",
-					"label":     fmt.Sprintf(`"{path: %s|pkg: %d}"`, info.DirectoryPath, info.ContainsPackageNum),
-				},
-			)); err != nil {
-				return err
-			}
-		} else {
-			if err = graph.AddNode("G", m.toDotLangFormat(nodeName), lo.Assign(
-				nodeDefaultAttrs,
-				map[string]string{
-					"fillcolor": "10",
-					"label":     fmt.Sprintf(`"{pkg: %s|path: %s}"`, info.Name, info.DirectoryPath),
-				},
-			)); err != nil {
-				return err
-			}
-		}
-	}
-
-	// add edge
-	for srcNodeName, relationMap := range m.nodeRelationCountMap() {
-		for dstNodeName, relationNum := range relationMap {
-			if m.isNgRelation(srcNodeName, dstNodeName) {
-				if err = graph.AddEdge(m.toDotLangFormat(srcNodeName), m.toDotLangFormat(dstNodeName), true, map[string]string{
-					"color":     `"red"`,
-					"weight":    fmt.Sprintf(`"%d"`, relationNum),
-					"label":     fmt.Sprintf(`"dep:%d"`, relationNum),
-					"fontcolor": `"white"`,
-					"decorate":  `"true"`,
-				}); err != nil {
-					return err
-				}
-			} else {
-				if err = graph.AddEdge(m.toDotLangFormat(srcNodeName), m.toDotLangFormat(dstNodeName), true, map[string]string{
-					"color":     `"white"`,
-					"weight":    fmt.Sprintf(`"%d"`, relationNum),
-					"label":     fmt.Sprintf(`"dep:%d"`, relationNum),
-					"fontcolor": `"white"`,
-					"decorate":  `"true"`,
-				}); err != nil {
-					return err
-				}
-			}
-		}
+	cycles := m.cycles()
+	var err error
+	switch m.format {
+	case FormatDot, "":
+		err = m.writeDot(cycles)
+	case FormatJSON:
+		err = m.writeJSON(cycles)
+	case FormatMermaid:
+		err = m.writeMermaid(cycles)
+	case FormatPlantUML:
+		err = m.writePlantUML(cycles)
+	case FormatSVG:
+		err = m.writeSVG(cycles)
+	case FormatPNG:
+		err = m.writePNG(cycles)
+	case FormatHTML:
+		err = m.writeHTML(cycles)
+	default:
+		err = fmt.Errorf("unsupported format: %s", m.format)
 	}
-
-	if _, err = fmt.Fprint(m.output, graph.String()); err != nil {
+	if err != nil {
 		return err
 	}
-	return nil
+	return m.writeCycles(cycles)
 }
 
 func (m *Prelviz) nodeInfoMap() map[string]*NodeInfo {
@@ -174,30 +260,66 @@ func (m *Prelviz) nodeInfoMap() map[string]*NodeInfo {
 					DirectoryPath:      m.groupingPackageDirectoryPath(pkgDirPath),
 					IsGrouping:         true,
 					ContainsPackageNum: 1,
+					Layer:              m.layerName(nodeName),
+					Module:             m.moduleName(pkgDirPath),
 				}
 			} else {
 				nodeInfoMap[nodeName] = &NodeInfo{
 					Name:               info.Name,
-					DirectoryPath:      pkgDirPath,
+					DirectoryPath:      info.DirectoryPath,
 					IsGrouping:         false,
 					ContainsPackageNum: 1,
+					Layer:              m.layerName(nodeName),
+					IsTest:             info.IsTest,
+					Module:             m.moduleName(info.DirectoryPath),
 				}
 			}
 		}
+		if m.stdlib == StdlibAnnotation {
+			m.addStdlibUsages(nodeInfoMap[nodeName], info)
+		}
 	}
+	m.addExternalNodeInfos(nodeInfoMap)
 	return nodeInfoMap
 }
 
+// nodeRelationCountMap returns the dependency count of every relation between nodes including the relations only from tests.
+// The count is selected by the label of the edges.
 func (m *Prelviz) nodeRelationCountMap() map[string]map[string]int {
+	return m.nodeRelationCountMapBy(m.label, true)
+}
+
+// nodeRelationCountMapOf returns the number of distinct identifiers of every relation between nodes.
+// The usages in tests are counted only when withTest is true.
+func (m *Prelviz) nodeRelationCountMapOf(withTest bool) map[string]map[string]int {
+	return m.nodeRelationCountMapBy(LabelIdentifiers, withTest)
+}
+
+// nodeRelationCountMapBy returns the count of every relation between nodes. The label selects what is counted:
+// the distinct identifiers used from each imported package, the references to them or the files which import them.
+// The usages in tests are counted only when withTest is true.
+func (m *Prelviz) nodeRelationCountMapBy(label string, withTest bool) map[string]map[string]int {
 	nodeRelationCountMap := make(map[string]map[string]int)
+	// the importing files of a relation are counted once even if they import several packages of the dst node.
+	nodeRelationFileMap := make(map[string]map[string]map[string]struct{})
+	isCountedFile := func(filePath string) bool {
+		return withTest || !isTestFilePath(filePath)
+	}
 	for pkgDirPath, info := range m.packageInfoMap {
 		if m.isExcludePackageWithDirPath(pkgDirPath) {
 			continue
 		}
+		if info.IsTest && !withTest {
+			continue
+		}
 
 		nodeName := m.nodeName(pkgDirPath)
-		for importPath, usageMap := range info.ImportUsageMap {
-			if !m.isTargetPackage(importPath) {
+		importUsageMap := info.ImportUsageMap
+		if withTest {
+			importUsageMap = unionSetMap(info.ImportUsageMap, info.TestImportUsageMap)
+		}
+		for importPath, usageMap := range importUsageMap {
+			if !m.isNodePackage(importPath) {
 				continue
 			}
 			importPathNodeName := m.importPathNodeName(importPath)
@@ -210,10 +332,29 @@ func (m *Prelviz) nodeRelationCountMap() map[string]map[string]int {
 			}
 
 			if _, ok := nodeRelationCountMap[nodeName]; !ok {
-				nodeRelationCountMap[nodeName] = map[string]int{
-					importPathNodeName: len(usageMap),
+				nodeRelationCountMap[nodeName] = make(map[string]int)
+			}
+			switch label {
+			case LabelReferences:
+				for _, positions := range info.UsagePositionMap[importPath] {
+					nodeRelationCountMap[nodeName][importPathNodeName] += len(lo.Filter(positions, func(p Position, _ int) bool {
+						return isCountedFile(p.FilePath)
+					}))
 				}
-			} else {
+			case LabelFiles:
+				if _, ok := nodeRelationFileMap[nodeName]; !ok {
+					nodeRelationFileMap[nodeName] = make(map[string]map[string]struct{})
+				}
+				if _, ok := nodeRelationFileMap[nodeName][importPathNodeName]; !ok {
+					nodeRelationFileMap[nodeName][importPathNodeName] = make(map[string]struct{})
+				}
+				for _, position := range info.ImportPositionMap[importPath] {
+					if isCountedFile(position.FilePath) {
+						nodeRelationFileMap[nodeName][importPathNodeName][position.FilePath] = struct{}{}
+					}
+				}
+				nodeRelationCountMap[nodeName][importPathNodeName] = len(nodeRelationFileMap[nodeName][importPathNodeName])
+			default:
 				nodeRelationCountMap[nodeName][importPathNodeName] += len(usageMap)
 			}
 		}
@@ -221,12 +362,74 @@ func (m *Prelviz) nodeRelationCountMap() map[string]map[string]int {
 	return nodeRelationCountMap
 }
 
+// unionSetMap returns the union of the sets of each key in the maps, such as the identifiers used from each imported package.
+func unionSetMap(importUsageMaps ...map[string]map[string]struct{}) map[string]map[string]struct{} {
+	union := make(map[string]map[string]struct{})
+	for _, importUsageMap := range importUsageMaps {
+		for importPath, usageMap := range importUsageMap {
+			if _, ok := union[importPath]; !ok {
+				union[importPath] = make(map[string]struct{}, len(usageMap))
+			}
+			for usage := range usageMap {
+				union[importPath][usage] = struct{}{}
+			}
+		}
+	}
+	return union
+}
+
+// nodeTestOnlyRelationMap returns the relations between nodes which only tests make.
+func (m *Prelviz) nodeTestOnlyRelationMap() map[string]map[string]struct{} {
+	relationMap := m.nodeRelationCountMapOf(false)
+	testOnlyRelationMap := make(map[string]map[string]struct{})
+	for srcNodeName, dstNodeMap := range m.nodeRelationCountMapOf(true) {
+		for dstNodeName := range dstNodeMap {
+			if _, ok := relationMap[srcNodeName][dstNodeName]; ok {
+				continue
+			}
+			if _, ok := testOnlyRelationMap[srcNodeName]; !ok {
+				testOnlyRelationMap[srcNodeName] = make(map[string]struct{})
+			}
+			testOnlyRelationMap[srcNodeName][dstNodeName] = struct{}{}
+		}
+	}
+	return testOnlyRelationMap
+}
+
+func isTestOnlyRelation(testOnlyRelationMap map[string]map[string]struct{}, from, to string) bool {
+	_, ok := testOnlyRelationMap[from][to]
+	return ok
+}
+
 func (m *Prelviz) importPathNodeName(importPath string) string {
-	dirPath := strings.TrimPrefix(importPath, m.projectModuleName)
-	dirPath = strings.TrimPrefix(dirPath, "/")
+	dirPath, ok := m.moduleWorkspace().PackageDirectoryPath(importPath)
+	if !ok {
+		return m.externalNodeName(importPath)
+	}
 	return m.nodeName(dirPath)
 }
 
+// moduleWorkspace returns the workspace of the project.
+func (m *Prelviz) moduleWorkspace() *Workspace {
+	if m.workspace == nil {
+		return NewModuleWorkspace(m.projectModuleName)
+	}
+	return m.workspace
+}
+
+// moduleName returns the path of the module which the directory belongs to, or an empty string for a single module project.
+func (m *Prelviz) moduleName(dirPath string) string {
+	workspace := m.moduleWorkspace()
+	if !workspace.IsMultiModule() {
+		return ""
+	}
+	module, ok := workspace.DirectoryModule(dirPath)
+	if !ok {
+		return ""
+	}
+	return module.Path
+}
+
 func (m *Prelviz) isGroupingNode(pkgDirPath string) bool {
 	if m.config == nil {
 		return false
@@ -239,31 +442,56 @@ func (m *Prelviz) groupingPackageDirectoryPath(pkgDirPath string) string {
 }
 
 func (m *Prelviz) nodeName(pkgDirPath string) string {
-	nodeName := filepath.Join(m.projectModuleName, pkgDirPath)
+	if info, ok := m.packageInfoMap[pkgDirPath]; ok && info.IsTest {
+		// an external test package is named after the package under test, even at the root of a module.
+		return m.nodeName(info.DirectoryPath) + testPackageSuffix
+	}
 	if m.config.IsGroupingPackage(pkgDirPath) {
-		return filepath.Join(m.projectModuleName, m.config.GroupingPackageDirectoryPath(pkgDirPath))
+		return m.moduleWorkspace().PackagePath(m.config.GroupingPackageDirectoryPath(pkgDirPath))
 	}
-
-	return nodeName
+	return m.moduleWorkspace().PackagePath(pkgDirPath)
 }
 
+// isTargetPackage reports whether the package belongs to a module of the project.
 func (m *Prelviz) isTargetPackage(importPath string) bool {
-	return strings.Contains(importPath, m.projectModuleName)
+	_, ok := m.moduleWorkspace().PackageModule(importPath)
+	return ok
+}
+
+func (m *Prelviz) isViolation(from, to string) bool {
+	return m.config.ViolatedRule(from, to) != ""
 }
 
-func (m *Prelviz) toDotLangFormat(in string) string {
-	return fmt.Sprintf(`"%s"`, in)
+func (m *Prelviz) isCyclicRelation(cyclicNodeMap map[string]int, from, to string) bool {
+	fromIndex, ok := cyclicNodeMap[from]
+	if !ok {
+		return false
+	}
+	toIndex, ok := cyclicNodeMap[to]
+	return ok && fromIndex == toIndex
 }
 
-func (m *Prelviz) isNgRelation(from, to string) bool {
-	return m.config.IsNgRelation(from, to)
+func (m *Prelviz) layerName(nodeName string) string {
+	if layerIndex := m.config.LayerIndex(nodeName); layerIndex >= 0 {
+		return m.config.Layers[layerIndex].Name
+	}
+	return ""
 }
 
+// isExcludePackage reports whether the imported package is excluded.
+// A package out of the project is also excluded by the name of its node, such as the module path with the module external mode.
 func (m *Prelviz) isExcludePackage(pkg string) bool {
-	return m.config.IsExcludePackage(pkg)
+	if m.config.IsExcludePackage(pkg) {
+		return true
+	}
+	return m.isExternalPackage(pkg) && m.config.IsExcludePackage(m.externalNodeName(pkg))
 }
 
+// isExcludePackageWithDirPath reports whether the package of the key in the package info map is excluded.
+// An external test package, whose key has the _test suffix, is excluded with the package under test.
 func (m *Prelviz) isExcludePackageWithDirPath(pkgDirPath string) bool {
-	pkg := filepath.Join(m.projectModuleName, pkgDirPath)
-	return m.config.IsExcludePackage(pkg)
+	if info, ok := m.packageInfoMap[pkgDirPath]; ok && info.IsTest {
+		pkgDirPath = info.DirectoryPath
+	}
+	return m.config.IsExcludePackage(m.moduleWorkspace().PackagePath(pkgDirPath))
 }
@@ -5,6 +5,67 @@ import (
 	"testing"
 )
 
+// newSamplePrelviz returns the Prelviz of the sample packages shared by the tests of the output formats.
+// sample/src uses two identifiers of sample/grouping/dst1, which violates ng_relation through the grouping node, and one of sample/dst2.
+func newSamplePrelviz() *Prelviz {
+	return &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"sample/src": {
+				Name:          "src",
+				DirectoryPath: "sample/src",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/sample/grouping/dst1": {"Sample1": {}, "Sample2": {}},
+					"mod/sample/dst2":          {"Sample3": {}},
+					"fmt":                      {"Println": {}},
+				},
+				ImportPositionMap: map[string][]Position{
+					"fmt":                      {{FilePath: "sample/src/src.go", Line: 4, Column: 2}},
+					"mod/sample/dst2":          {{FilePath: "sample/src/src.go", Line: 6, Column: 2}},
+					"mod/sample/grouping/dst1": {{FilePath: "sample/src/src.go", Line: 7, Column: 2}},
+				},
+				UsagePositionMap: map[string]map[string][]Position{
+					"fmt": {
+						"Println": {{FilePath: "sample/src/src.go", Line: 11, Column: 2, Func: "Run"}},
+					},
+					"mod/sample/dst2": {
+						"Sample3": {{FilePath: "sample/src/src.go", Line: 12, Column: 2, Func: "Run"}},
+					},
+					"mod/sample/grouping/dst1": {
+						"Sample1": {{FilePath: "sample/src/src.go", Line: 14, Column: 2, Func: "Run"}},
+						"Sample2": {{FilePath: "sample/src/src.go", Line: 13, Column: 7, Func: "Run"}},
+					},
+				},
+				FilePaths: []string{"sample/src/src.go"},
+				ImportTypeMap: map[string]map[string]struct{}{
+					"mod/sample/grouping/dst1": {ImportTypeNormal: {}},
+					"mod/sample/dst2":          {ImportTypeNormal: {}},
+					"fmt":                      {ImportTypeNormal: {}},
+				},
+			},
+			"sample/grouping/dst1": {
+				Name:           "dst1",
+				DirectoryPath:  "sample/grouping/dst1",
+				ImportUsageMap: map[string]map[string]struct{}{},
+				FilePaths:      []string{"sample/grouping/dst1/dst1.go"},
+			},
+			"sample/dst2": {
+				Name:           "dst2",
+				DirectoryPath:  "sample/dst2",
+				ImportUsageMap: map[string]map[string]struct{}{},
+				FilePaths:      []string{"sample/dst2/dst2.go"},
+			},
+		},
+		config: &Config{
+			NgRelationMap: map[string]map[string]struct{}{
+				"mod/sample/src": {"mod/sample/grouping": {}},
+			},
+			GroupingDirectoryPaths: []string{"sample/grouping"},
+			ExcludePackageMap:      make(map[string]struct{}),
+		},
+	}
+}
+
 func TestPrelviz_nodeInfoMap(t *testing.T) {
 	type fields struct {
 		projectModuleName string
@@ -522,3 +583,190 @@ func TestPrelviz_nodeName(t *testing.T) {
 		})
 	}
 }
+
+func TestPrelviz_nodeTestOnlyRelationMap(t *testing.T) {
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"sample/src": {
+				Name:          "src",
+				DirectoryPath: "sample/src",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/sample/dst1": {"Sample1": {}},
+				},
+				TestImportUsageMap: map[string]map[string]struct{}{
+					"mod/sample/dst1": {"Helper1": {}},
+					"mod/sample/dst2": {"Helper2": {}},
+				},
+			},
+			"sample/src_test": {
+				Name:          "src_test",
+				DirectoryPath: "sample/src",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/sample/src": {"Run": {}},
+				},
+				IsTest: true,
+			},
+		},
+		config: &Config{
+			NgRelationMap:          make(map[string]map[string]struct{}),
+			GroupingDirectoryPaths: make([]string, 0),
+			ExcludePackageMap:      make(map[string]struct{}),
+		},
+	}
+	want := map[string]map[string]struct{}{
+		"mod/sample/src":      {"mod/sample/dst2": {}},
+		"mod/sample/src_test": {"mod/sample/src": {}},
+	}
+	if got := m.nodeTestOnlyRelationMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Prelviz.nodeTestOnlyRelationMap() = %v, want %v", got, want)
+	}
+	wantCountMap := map[string]map[string]int{
+		"mod/sample/src":      {"mod/sample/dst1": 2, "mod/sample/dst2": 1},
+		"mod/sample/src_test": {"mod/sample/src": 1},
+	}
+	if got := m.nodeRelationCountMap(); !reflect.DeepEqual(got, wantCountMap) {
+		t.Errorf("Prelviz.nodeRelationCountMap() = %v, want %v", got, wantCountMap)
+	}
+	wantNodeInfo := &NodeInfo{Name: "src_test", DirectoryPath: "sample/src", ContainsPackageNum: 1, IsTest: true}
+	if got := m.nodeInfoMap()["mod/sample/src_test"]; !reflect.DeepEqual(got, wantNodeInfo) {
+		t.Errorf("Prelviz.nodeInfoMap() of the external test package = %v, want %v", got, wantNodeInfo)
+	}
+}
+
+func TestPrelviz_isExcludePackageWithDirPath(t *testing.T) {
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"sample/src": {
+				Name:          "src",
+				DirectoryPath: "sample/src",
+			},
+			"sample/src_test": {
+				Name:          "src_test",
+				DirectoryPath: "sample/src",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/sample/src": {"Run": {}},
+				},
+				IsTest: true,
+			},
+			"sample/dst": {
+				Name:          "dst",
+				DirectoryPath: "sample/dst",
+			},
+		},
+		config: &Config{
+			NgRelationMap:          make(map[string]map[string]struct{}),
+			GroupingDirectoryPaths: make([]string, 0),
+			ExcludePackageMap:      map[string]struct{}{"mod/sample/src": {}},
+		},
+	}
+	for pkgDirPath, want := range map[string]bool{
+		"sample/src":      true,
+		"sample/src_test": true,
+		"sample/dst":      false,
+	} {
+		if got := m.isExcludePackageWithDirPath(pkgDirPath); got != want {
+			t.Errorf("Prelviz.isExcludePackageWithDirPath(%s) = %v, want %v", pkgDirPath, got, want)
+		}
+	}
+	if got := sortedKeys(m.nodeInfoMap()); !reflect.DeepEqual(got, []string{"mod/sample/dst"}) {
+		t.Errorf("Prelviz.nodeInfoMap() nodes = %v, want only mod/sample/dst", got)
+	}
+}
+
+func TestNewPrelviz_workspace(t *testing.T) {
+	m, err := NewPrelviz("testdata/module_test/workspace", "", &Option{})
+	if err != nil {
+		t.Fatalf("NewPrelviz() error = %v", err)
+	}
+	graph := m.jsonGraph(m.cycles())
+
+	if graph.Module != "" {
+		t.Errorf("jsonGraph().Module = %v, want empty for a workspace of multiple modules", graph.Module)
+	}
+	wantModules := []string{"example.com/a", "example.com/b"}
+	if !reflect.DeepEqual(graph.Modules, wantModules) {
+		t.Errorf("jsonGraph().Modules = %v, want %v", graph.Modules, wantModules)
+	}
+	if typeGraph := m.typeGraph(); typeGraph.Module != "" || !reflect.DeepEqual(typeGraph.Modules, wantModules) {
+		t.Errorf("typeGraph() Module = %v, Modules = %v, want empty and %v", typeGraph.Module, typeGraph.Modules, wantModules)
+	}
+	if got, want := m.title(), "example.com/a, example.com/b"; got != want {
+		t.Errorf("Prelviz.title() = %v, want %v", got, want)
+	}
+	nodeModuleMap := make(map[string]string)
+	for _, node := range graph.Nodes {
+		nodeModuleMap[node.ID] = node.Module
+	}
+	wantNodeModuleMap := map[string]string{
+		"example.com/a/domain": "example.com/a",
+		"example.com/b/app":    "example.com/b",
+	}
+	if !reflect.DeepEqual(nodeModuleMap, wantNodeModuleMap) {
+		t.Errorf("jsonGraph() node modules = %v, want %v", nodeModuleMap, wantNodeModuleMap)
+	}
+	if len(graph.Edges) != 1 {
+		t.Fatalf("jsonGraph().Edges = %v, want the cross-module edge", graph.Edges)
+	}
+	edge := graph.Edges[0]
+	if edge.From != "example.com/b/app" || edge.To != "example.com/a/domain" || edge.ViolatedRule != RuleNgRelation {
+		t.Errorf("jsonGraph().Edges[0] = %+v, want the ng_relation violation from example.com/b/app to example.com/a/domain", edge)
+	}
+}
+
+func TestPrelviz_nodeRelationCountMapBy(t *testing.T) {
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"src": {
+				Name:          "src",
+				DirectoryPath: "src",
+				ImportUsageMap: map[string]map[string]struct{}{
+					"mod/dst/a": {"A": {}, "B": {}},
+					"mod/dst/b": {"C": {}},
+				},
+				TestImportUsageMap: map[string]map[string]struct{}{
+					"mod/dst/a": {"A": {}, "D": {}},
+				},
+				ImportPositionMap: map[string][]Position{
+					"mod/dst/a": {{FilePath: "src/a.go"}, {FilePath: "src/b.go"}, {FilePath: "src/a_test.go"}},
+					"mod/dst/b": {{FilePath: "src/a.go"}},
+				},
+				UsagePositionMap: map[string]map[string][]Position{
+					"mod/dst/a": {
+						"A": {{FilePath: "src/a.go"}, {FilePath: "src/b.go"}, {FilePath: "src/a_test.go"}},
+						"B": {{FilePath: "src/b.go"}},
+						"D": {{FilePath: "src/a_test.go"}},
+					},
+					"mod/dst/b": {"C": {{FilePath: "src/a.go"}}},
+				},
+			},
+		},
+		config: &Config{
+			GroupingDirectoryPaths: []string{"dst"},
+			ExcludePackageMap:      make(map[string]struct{}),
+		},
+	}
+	tests := []struct {
+		name     string
+		label    string
+		withTest bool
+		want     int
+	}{
+		{name: "normal: distinct identifiers", label: LabelIdentifiers, withTest: false, want: 3},
+		{name: "normal: distinct identifiers with tests", label: LabelIdentifiers, withTest: true, want: 4},
+		{name: "normal: references", label: LabelReferences, withTest: false, want: 4},
+		{name: "normal: references with tests", label: LabelReferences, withTest: true, want: 6},
+		{name: "normal: importing files", label: LabelFiles, withTest: false, want: 2},
+		{name: "normal: importing files with tests", label: LabelFiles, withTest: true, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.nodeRelationCountMapBy(tt.label, tt.withTest)
+			if got["mod/src"]["mod/dst"] != tt.want {
+				t.Errorf("Prelviz.nodeRelationCountMapBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package prelviz
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Server serves the report of a project and reloads it in the browser
+// when go files, go.mod, go.work or the config of the project change.
+type Server struct {
+	projectDirectoryPath string
+	option               *Option
+	// format is the format of the report. The html report reloads itself in the browser.
+	format     string
+	loadOption *LoadOption
+	interval   time.Duration
+	errOutput  io.Writer
+
+	mu                  sync.Mutex
+	workspace           *Workspace
+	workspaceModTimeMap map[string]time.Time
+	fileCacheMap        map[string]*fileCache
+	// packageInfoMap is the packages loaded by the packages loader. The ast loader merges the file caches instead.
+	packageInfoMap map[string]*PackageInfo
+	config         *Config
+	configModTime  time.Time
+	page           []byte
+	version        int
+	lastErr        string
+	subscribers    map[chan int]struct{}
+}
+
+// fileCache is the package info of a go file parsed at the modification time.
+// The info is nil with the packages loader, which loads all the packages again when a file changes.
+type fileCache struct {
+	modTime time.Time
+	info    *PackageInfo
+}
+
+// serverContentTypeMap is the content type of each format of the report.
+var serverContentTypeMap = map[string]string{
+	FormatHTML: "text/html; charset=utf-8",
+	FormatSVG:  "image/svg+xml",
+	FormatPNG:  "image/png",
+	FormatJSON: "application/json",
+}
+
+// ServerWatchInterval is the interval of polling the project for changes.
+const ServerWatchInterval = time.Second
+
+// NewServer loads the project by the option and renders the first report. The format of the report defaults to html.
+func NewServer(projectDirectoryPath string, option *Option) (*Server, error) {
+	if err := validateExternal(option.External); err != nil {
+		return nil, err
+	}
+	if err := validateStdlib(option.Stdlib); err != nil {
+		return nil, err
+	}
+	if err := validateLabel(option.Label); err != nil {
+		return nil, err
+	}
+	if err := validateLevel(option.Level); err != nil {
+		return nil, err
+	}
+	if err := validateLoader(option.Loader); err != nil {
+		return nil, err
+	}
+
+	format := option.Format
+	if format == "" {
+		format = FormatHTML
+	}
+	s := &Server{
+		projectDirectoryPath: projectDirectoryPath,
+		option:               option,
+		format:               format,
+		loadOption:           option.loadOption(),
+		interval:             ServerWatchInterval,
+		errOutput:            os.Stderr,
+		fileCacheMap:         make(map[string]*fileCache),
+		subscribers:          make(map[chan int]struct{}),
+	}
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListenAndServe watches the project and serves the report on addr.
+func (s *Server) ListenAndServe(addr string) error {
+	go s.watch()
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+// Handler returns the handler which serves the report on "/" and the reload events on "/events".
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handlePage)
+	mux.HandleFunc("/events", s.handleEvents)
+	return mux
+}
+
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	page := s.page
+	s.mu.Unlock()
+	contentType, ok := serverContentTypeMap[s.format]
+	if !ok {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(page)
+}
+
+// handleEvents pushes the version of the report as server-sent events whenever the report is re-rendered.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case version := <-ch:
+			if _, err := fmt.Fprintf(w, "data: %d\n\n", version); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) subscribe() chan int {
+	ch := make(chan int, 1)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *Server) unsubscribe(ch chan int) {
+	s.mu.Lock()
+	delete(s.subscribers, ch)
+	s.mu.Unlock()
+}
+
+// watch polls the project for changes and reloads the report. Errors are written once until they change,
+// and the last report is kept while the project can not be parsed.
+func (s *Server) watch() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		_, err := s.reload()
+		message := ""
+		if err != nil {
+			message = err.Error()
+		}
+		if message != s.lastErr && message != "" {
+			fmt.Fprintln(s.errOutput, message)
+		}
+		s.lastErr = message
+	}
+}
+
+// reload re-creates the workspace if go.work or go.mod is modified, parses the go files which are added or modified
+// since the last reload and the config if it is modified, then re-renders the report. It returns whether the report is re-rendered.
+// With the ast loader, a go file which can not be parsed keeps the last parsed package info.
+// With the packages loader, all the packages are loaded again when any go file changes, and the last packages are kept on failure.
+func (s *Server) reload() (bool, error) {
+	changed := false
+	if workspaceModTimeMap := s.workspaceModTimes(); s.workspace == nil || !equalModTimeMap(workspaceModTimeMap, s.workspaceModTimeMap) {
+		workspace, err := NewWorkspace(s.projectDirectoryPath)
+		if err != nil {
+			return false, err
+		}
+		s.workspace = workspace
+		// modules may be added to or removed from go.work, so their go.mod files are listed again.
+		s.workspaceModTimeMap = s.workspaceModTimes()
+		// the directory paths in the config are converted to the package paths by the workspace.
+		s.config = nil
+		changed = true
+	}
+
+	filePaths, err := goFilePathsOfDirectories(s.projectDirectoryPath, s.workspace.DirectoryPaths(), s.loadOption)
+	if err != nil {
+		return false, err
+	}
+
+	filesChanged := changed
+	errs := make([]error, 0)
+	existMap := make(map[string]struct{}, len(filePaths))
+	for _, filePath := range filePaths {
+		existMap[filePath] = struct{}{}
+		stat, err := os.Stat(filePath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if cache, ok := s.fileCacheMap[filePath]; ok && cache.modTime.Equal(stat.ModTime()) {
+			continue
+		}
+		filesChanged = true
+		if s.option.Loader == LoaderPackages {
+			s.fileCacheMap[filePath] = &fileCache{modTime: stat.ModTime()}
+			continue
+		}
+		info, err := NewPackageInfo(filePath, s.projectDirectoryPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.fileCacheMap[filePath] = &fileCache{modTime: stat.ModTime(), info: info}
+		changed = true
+	}
+	for filePath := range s.fileCacheMap {
+		if _, ok := existMap[filePath]; !ok {
+			delete(s.fileCacheMap, filePath)
+			filesChanged = true
+			changed = true
+		}
+	}
+	if filesChanged && s.option.Loader == LoaderPackages {
+		packageInfoMap, err := loadPackageInfoMap(s.projectDirectoryPath, s.workspace, LoaderPackages, s.loadOption, s.errOutput)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			s.packageInfoMap = packageInfoMap
+			changed = true
+		}
+	}
+
+	var configModTime time.Time
+	if stat, err := os.Stat(filepath.Join(s.projectDirectoryPath, configJsonName)); err == nil {
+		configModTime = stat.ModTime()
+	}
+	if s.config == nil || !configModTime.Equal(s.configModTime) {
+		config, err := NewWorkspaceConfig(s.projectDirectoryPath, s.workspace)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			s.config = config
+			s.configModTime = configModTime
+			changed = true
+		}
+	}
+
+	if changed && s.config != nil {
+		if err = s.render(); err != nil {
+			errs = append(errs, err)
+			changed = false
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// workspaceModTimes returns the modification times of go.work and the go.mod files of the modules, which define the workspace.
+// A file which does not exist has the zero time.
+func (s *Server) workspaceModTimes() map[string]time.Time {
+	filePaths := []string{
+		filepath.Join(s.projectDirectoryPath, workFileName),
+		filepath.Join(s.projectDirectoryPath, "go.mod"),
+	}
+	if s.workspace != nil {
+		for _, dirPath := range s.workspace.DirectoryPaths() {
+			filePaths = append(filePaths, filepath.Join(s.projectDirectoryPath, dirPath, "go.mod"))
+		}
+	}
+	modTimeMap := make(map[string]time.Time, len(filePaths))
+	for _, filePath := range filePaths {
+		var modTime time.Time
+		if stat, err := os.Stat(filePath); err == nil {
+			modTime = stat.ModTime()
+		}
+		modTimeMap[filePath] = modTime
+	}
+	return modTimeMap
+}
+
+func equalModTimeMap(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for filePath, modTime := range a {
+		if other, ok := b[filePath]; !ok || !modTime.Equal(other) {
+			return false
+		}
+	}
+	return true
+}
+
+// render renders the report from the loaded packages and notifies the subscribers.
+func (s *Server) render() error {
+	packageInfoMap := s.packageInfoMap
+	if s.option.Loader != LoaderPackages {
+		fileInfos := make([]*PackageInfo, 0, len(s.fileCacheMap))
+		for _, filePath := range sortedKeys(s.fileCacheMap) {
+			fileInfos = append(fileInfos, s.fileCacheMap[filePath].info)
+		}
+		packageInfoMap = mergePackageInfos(fileInfos)
+	}
+
+	var page bytes.Buffer
+	m := &Prelviz{
+		projectModuleName: s.workspace.ProjectModulePath(),
+		workspace:         s.workspace,
+		packageInfoMap:    packageInfoMap,
+		config:            s.config,
+		output:            &page,
+		dotLayout:         s.option.DotLayout,
+		format:            s.format,
+		external:          s.option.External,
+		stdlib:            s.option.Stdlib,
+		label:             s.option.Label,
+		level:             s.option.Level,
+	}
+	if s.format == FormatHTML && m.level != LevelType {
+		report, err := m.htmlReport(m.cycles())
+		if err != nil {
+			return err
+		}
+		report.LiveReload = true
+		if err = reportTemplate.Execute(&page, report); err != nil {
+			return err
+		}
+	} else if err := m.Run(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.page = page.Bytes()
+	s.version++
+	for ch := range s.subscribers {
+		select {
+		case ch <- s.version:
+		default:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package prelviz
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeServerTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_reload(t *testing.T) {
+	dir := t.TempDir()
+	writeServerTestFile(t, filepath.Join(dir, "go.mod"), "module sample\n")
+	writeServerTestFile(t, filepath.Join(dir, "app", "app.go"), "package app\n")
+
+	s, err := NewServer(dir, &Option{DotLayout: "dot"})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if !strings.Contains(string(s.page), `data-node="sample/app"`) {
+		t.Errorf("Server.page do not contain the node sample/app")
+	}
+
+	if changed, err := s.reload(); err != nil || changed {
+		t.Errorf("Server.reload() = %v, %v, want false, nil without changes", changed, err)
+	}
+
+	writeServerTestFile(t, filepath.Join(dir, "domain", "domain.go"), "package domain\n\nfunc Do() {}\n")
+	appPath := filepath.Join(dir, "app", "app.go")
+	writeServerTestFile(t, appPath, "package app\n\nimport \"sample/domain\"\n\nfunc Run() { domain.Do() }\n")
+	later := time.Now().Add(time.Minute)
+	if err = os.Chtimes(appPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := s.reload(); err != nil || !changed {
+		t.Fatalf("Server.reload() = %v, %v, want true, nil after changes", changed, err)
+	}
+	page := string(s.page)
+	for _, want := range []string{
+		`data-node="sample/domain"`,
+		`<g class="edge" data-from="sample/app" data-to="sample/domain">`,
+		`new EventSource("/events")`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Server.page do not contain %s", want)
+		}
+	}
+	if s.version != 2 {
+		t.Errorf("Server.version = %d, want 2", s.version)
+	}
+
+	writeServerTestFile(t, appPath, "package app\n\nfunc Run() {\n")
+	if err = os.Chtimes(appPath, later.Add(time.Minute), later.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := s.reload(); err == nil || changed {
+		t.Errorf("Server.reload() = %v, %v, want false and an error for a broken file", changed, err)
+	}
+	if !strings.Contains(string(s.page), `data-from="sample/app" data-to="sample/domain"`) {
+		t.Errorf("Server.page do not keep the last report for a broken file")
+	}
+
+	if err = os.Remove(filepath.Join(dir, "domain", "domain.go")); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := s.reload(); !changed {
+		t.Errorf("Server.reload() = false, want true after removing a file")
+	}
+	if strings.Contains(string(s.page), `data-node="sample/domain"`) {
+		t.Errorf("Server.page contains the removed node sample/domain")
+	}
+}
+
+func TestServer_Handler(t *testing.T) {
+	dir := t.TempDir()
+	writeServerTestFile(t, filepath.Join(dir, "go.mod"), "module sample\n")
+	writeServerTestFile(t, filepath.Join(dir, "app", "app.go"), "package app\n")
+	s, err := NewServer(dir, &Option{DotLayout: "dot"})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	res, err := server.Client().Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	defer res.Body.Close()
+	if got := res.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("GET /events Content-Type = %s, want text/event-stream", got)
+	}
+	for {
+		s.mu.Lock()
+		subscribed := len(s.subscribers) > 0
+		s.mu.Unlock()
+		if subscribed {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err = s.render(); err != nil {
+		t.Fatalf("Server.render() error = %v", err)
+	}
+	buf := make([]byte, len("data: 2\n\n"))
+	if _, err = io.ReadFull(res.Body, buf); err != nil {
+		t.Fatalf("read /events error = %v", err)
+	}
+	if got := string(buf); got != "data: 2\n\n" {
+		t.Errorf("GET /events = %q, want %q", got, "data: 2\n\n")
+	}
+
+	page, err := server.Client().Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / error = %v", err)
+	}
+	defer page.Body.Close()
+	body, _ := io.ReadAll(page.Body)
+	if !strings.Contains(string(body), `data-node="sample/app"`) {
+		t.Errorf("GET / do not contain the node sample/app")
+	}
+}
+
+func TestServer_reload_workspaceAndConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeServerTestFile(t, filepath.Join(dir, "go.mod"), "module sample\n")
+	writeServerTestFile(t, filepath.Join(dir, "app", "app.go"), "package app\n")
+
+	s, err := NewServer(dir, &Option{DotLayout: "dot", Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+
+	modPath := filepath.Join(dir, "go.mod")
+	writeServerTestFile(t, modPath, "module renamed\n")
+	if err = os.Chtimes(modPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := s.reload(); err != nil || !changed {
+		t.Fatalf("Server.reload() = %v, %v, want true, nil after changing go.mod", changed, err)
+	}
+	if !strings.Contains(string(s.page), `"id": "renamed/app"`) {
+		t.Errorf("Server.page = %s, want the node renamed/app", s.page)
+	}
+
+	writeServerTestFile(t, filepath.Join(dir, configJsonName), `{"exclude_package": ["renamed/app"]}`)
+	if changed, err := s.reload(); err != nil || !changed {
+		t.Fatalf("Server.reload() = %v, %v, want true, nil after adding the config", changed, err)
+	}
+	if strings.Contains(string(s.page), `"id": "renamed/app"`) {
+		t.Errorf("Server.page = %s, want no excluded node", s.page)
+	}
+}
+
+func TestNewServer_option(t *testing.T) {
+	dir := t.TempDir()
+	writeServerTestFile(t, filepath.Join(dir, "go.mod"), "module sample\n\ngo 1.21\n")
+	writeServerTestFile(t, filepath.Join(dir, "domain", "domain.go"), "package domain\n\ntype User struct{}\n")
+	writeServerTestFile(t, filepath.Join(dir, "app", "app.go"), "package app\n\nimport \"sample/domain\"\n\nfunc Run() domain.User { return domain.User{} }\n")
+
+	s, err := NewServer(dir, &Option{Format: FormatJSON, Level: LevelType})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if !strings.Contains(string(s.page), `"from": "sample/app.Run"`) {
+		t.Errorf("Server.page = %s, want the type level graph", s.page)
+	}
+
+	if _, err = NewServer(dir, &Option{Level: LevelType}); err == nil {
+		t.Errorf("NewServer() error = nil, want an error for the html report of the type level")
+	}
+
+	s, err = NewServer(dir, &Option{Format: FormatJSON, Loader: LoaderPackages})
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	if !strings.Contains(string(s.page), `"kind_counts"`) {
+		t.Errorf("Server.page = %s, want the kinds of the identifiers by the packages loader", s.page)
+	}
+	appPath := filepath.Join(dir, "app", "app.go")
+	writeServerTestFile(t, appPath, "package app\n")
+	later := time.Now().Add(time.Minute)
+	if err = os.Chtimes(appPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := s.reload(); err != nil || !changed {
+		t.Fatalf("Server.reload() = %v, %v, want true, nil after changes", changed, err)
+	}
+	if strings.Contains(string(s.page), `"from": "sample/app"`) {
+		t.Errorf("Server.page = %s, want no edge after removing the import", s.page)
+	}
+}
@@ -0,0 +1,97 @@
+package prelviz
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// StdlibNone does not draw the packages of the standard library.
+	StdlibNone = "none"
+	// StdlibNode draws every imported package of the standard library as a node in the standard library group.
+	StdlibNode = "node"
+	// StdlibAnnotation lists the packages of the standard library and the identifiers used in the label of each node.
+	StdlibAnnotation = "annotation"
+)
+
+const (
+	// ImportKindInternal is the kind of a package of the project.
+	ImportKindInternal = "internal"
+	// ImportKindStdlib is the kind of a package of the standard library.
+	ImportKindStdlib = "stdlib"
+	// ImportKindExternal is the kind of a package out of the project and the standard library.
+	ImportKindExternal = "external"
+)
+
+// stdlibGroupLabel is the label of the group of the standard library nodes.
+const stdlibGroupLabel = "standard library"
+
+// validateStdlib returns an error for an unsupported stdlib mode. The empty mode follows the external mode.
+func validateStdlib(stdlib string) error {
+	switch stdlib {
+	case StdlibNone, StdlibNode, StdlibAnnotation, "":
+		return nil
+	default:
+		return fmt.Errorf("unsupported stdlib mode: %s", stdlib)
+	}
+}
+
+// isStdlibPackage reports whether the import path is of the standard library.
+// Like the go command, a path whose first element has no dot is of the standard library.
+func isStdlibPackage(importPath string) bool {
+	firstElement, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(firstElement, ".")
+}
+
+// importKind classifies the imported package as internal, stdlib or external. The pseudo package "C" of cgo is external.
+// The modules of the workspace and the modules required by go.mod are not of the standard library even if their paths have no dot,
+// such as a module replaced by a local directory.
+func (m *Prelviz) importKind(importPath string) string {
+	switch {
+	case m.isTargetPackage(importPath):
+		return ImportKindInternal
+	case importPath == cgoImportPath:
+		return ImportKindExternal
+	case m.isRequiredPackage(importPath):
+		return ImportKindExternal
+	case isStdlibPackage(importPath):
+		return ImportKindStdlib
+	default:
+		return ImportKindExternal
+	}
+}
+
+// isRequiredPackage reports whether the package belongs to a module required by go.mod.
+func (m *Prelviz) isRequiredPackage(importPath string) bool {
+	_, ok := m.moduleWorkspace().RequiredModulePath(importPath)
+	return ok
+}
+
+// addStdlibUsages adds the identifiers of the standard library which the package uses to the node.
+func (m *Prelviz) addStdlibUsages(nodeInfo *NodeInfo, info *PackageInfo) {
+	for _, importUsageMap := range []map[string]map[string]struct{}{info.ImportUsageMap, info.TestImportUsageMap} {
+		for importPath, usageMap := range importUsageMap {
+			if m.importKind(importPath) != ImportKindStdlib || m.isExcludePackage(importPath) {
+				continue
+			}
+			if nodeInfo.StdlibUsageMap == nil {
+				nodeInfo.StdlibUsageMap = make(map[string]map[string]struct{})
+			}
+			if _, ok := nodeInfo.StdlibUsageMap[importPath]; !ok {
+				nodeInfo.StdlibUsageMap[importPath] = make(map[string]struct{})
+			}
+			for usage := range usageMap {
+				nodeInfo.StdlibUsageMap[importPath][usage] = struct{}{}
+			}
+		}
+	}
+}
+
+// stdlibUsageLines returns the annotation lines of the node, such as "os: Getenv, Open".
+func stdlibUsageLines(info *NodeInfo) []string {
+	lines := make([]string, 0, len(info.StdlibUsageMap))
+	for _, importPath := range sortedKeys(info.StdlibUsageMap) {
+		lines = append(lines, fmt.Sprintf("%s: %s", importPath, strings.Join(sortedKeys(info.StdlibUsageMap[importPath]), ", ")))
+	}
+	return lines
+}
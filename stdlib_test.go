@@ -0,0 +1,146 @@
+package prelviz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrelviz_importKind(t *testing.T) {
+	tests := []struct {
+		name       string
+		importPath string
+		want       string
+	}{
+		{
+			name:       "normal: package of the project",
+			importPath: "mod/app",
+			want:       ImportKindInternal,
+		},
+		{
+			name:       "normal: package of the standard library",
+			importPath: "net/http",
+			want:       ImportKindStdlib,
+		},
+		{
+			name:       "normal: package of another module",
+			importPath: "github.com/aws/aws-sdk-go-v2/aws",
+			want:       ImportKindExternal,
+		},
+		{
+			name:       "normal: package of a module of the workspace whose path has no dot",
+			importPath: "tools/lint",
+			want:       ImportKindInternal,
+		},
+		{
+			name:       "normal: package of a required module whose path has no dot",
+			importPath: "internaltools/log",
+			want:       ImportKindExternal,
+		},
+	}
+	workspace := &Workspace{
+		Modules: []*Module{
+			{Path: "mod", DirectoryPath: "."},
+			{Path: "tools", DirectoryPath: "tools"},
+		},
+		RequiredModulePaths: []string{"github.com/aws/aws-sdk-go-v2", "internaltools"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Prelviz{projectModuleName: "mod", workspace: workspace}
+			if got := m.importKind(tt.importPath); got != tt.want {
+				t.Errorf("Prelviz.importKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrelviz_stdlib(t *testing.T) {
+	packageInfoMap := map[string]*PackageInfo{
+		"app": {
+			Name:          "app",
+			DirectoryPath: "app",
+			ImportUsageMap: map[string]map[string]struct{}{
+				"os":                 {"Getenv": {}, "Open": {}},
+				"net/http":           {"Get": {}},
+				"github.com/aws/aws": {"String": {}},
+			},
+			TestImportUsageMap: map[string]map[string]struct{}{
+				"reflect": {"DeepEqual": {}},
+			},
+		},
+	}
+	tests := []struct {
+		name               string
+		external           string
+		stdlib             string
+		wantExternalNodes  map[string]bool
+		wantStdlibUsageMap map[string]map[string]struct{}
+	}{
+		{
+			name:              "normal: stdlib follows the external mode",
+			external:          ExternalPackage,
+			stdlib:            "",
+			wantExternalNodes: map[string]bool{"os": true, "net/http": true, "reflect": true, "github.com/aws/aws": false},
+		},
+		{
+			name:              "normal: stdlib is hidden while external packages are drawn",
+			external:          ExternalPackage,
+			stdlib:            StdlibNone,
+			wantExternalNodes: map[string]bool{"github.com/aws/aws": false},
+		},
+		{
+			name:              "normal: stdlib nodes without external packages",
+			external:          ExternalNone,
+			stdlib:            StdlibNode,
+			wantExternalNodes: map[string]bool{"os": true, "net/http": true, "reflect": true},
+		},
+		{
+			name:              "normal: stdlib annotation",
+			external:          ExternalNone,
+			stdlib:            StdlibAnnotation,
+			wantExternalNodes: map[string]bool{},
+			wantStdlibUsageMap: map[string]map[string]struct{}{
+				"os":       {"Getenv": {}, "Open": {}},
+				"net/http": {"Get": {}},
+				"reflect":  {"DeepEqual": {}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Prelviz{
+				projectModuleName: "mod",
+				packageInfoMap:    packageInfoMap,
+				config:            &Config{ExcludePackageMap: make(map[string]struct{})},
+				external:          tt.external,
+				stdlib:            tt.stdlib,
+			}
+			nodeInfoMap := m.nodeInfoMap()
+			externalNodes := make(map[string]bool)
+			for nodeName, info := range nodeInfoMap {
+				if info.IsExternal {
+					externalNodes[nodeName] = info.IsStdlib
+				}
+			}
+			if !reflect.DeepEqual(externalNodes, tt.wantExternalNodes) {
+				t.Errorf("Prelviz.nodeInfoMap() external nodes = %v, want %v", externalNodes, tt.wantExternalNodes)
+			}
+			if got := nodeInfoMap["mod/app"].StdlibUsageMap; !reflect.DeepEqual(got, tt.wantStdlibUsageMap) {
+				t.Errorf("Prelviz.nodeInfoMap() StdlibUsageMap = %v, want %v", got, tt.wantStdlibUsageMap)
+			}
+		})
+	}
+}
+
+func Test_stdlibUsageLines(t *testing.T) {
+	info := &NodeInfo{
+		StdlibUsageMap: map[string]map[string]struct{}{
+			"os":       {"Open": {}, "Getenv": {}},
+			"net/http": {"Get": {}},
+		},
+	}
+	want := []string{"net/http: Get", "os: Getenv, Open"}
+	if got := stdlibUsageLines(info); !reflect.DeepEqual(got, want) {
+		t.Errorf("stdlibUsageLines() = %v, want %v", got, want)
+	}
+}
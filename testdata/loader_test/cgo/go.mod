@@ -0,0 +1,3 @@
+module cgosample
+
+go 1.20
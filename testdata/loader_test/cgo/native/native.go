@@ -0,0 +1,12 @@
+package native
+
+// #include <stdlib.h>
+import "C"
+
+import "strings"
+
+func Upper(s string) string {
+	p := C.malloc(1)
+	C.free(p)
+	return strings.ToUpper(s)
+}
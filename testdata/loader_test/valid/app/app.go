@@ -0,0 +1,7 @@
+package app
+
+import "sample/yaml-go"
+
+func Run() {
+	_, _ = yaml.Marshal(nil)
+}
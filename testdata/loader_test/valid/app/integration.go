@@ -0,0 +1,9 @@
+//go:build integration
+
+package app
+
+import "fmt"
+
+func Integration() {
+	fmt.Println("integration")
+}
@@ -0,0 +1,5 @@
+
+// the module directive is not on the first line
+module sample/comment
+
+go 1.21
@@ -0,0 +1,14 @@
+// sample is a module with every directive.
+
+module "example.com/full"
+
+go 1.22
+
+require (
+	example.com/dep v1.2.0
+	example.com/indirect v0.1.0 // indirect
+)
+
+replace example.com/dep v1.2.0 => ../dep
+
+retract [v1.0.0, v1.0.5] // published with a broken api
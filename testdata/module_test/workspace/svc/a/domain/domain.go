@@ -0,0 +1,5 @@
+package domain
+
+type User struct {
+	Name string
+}
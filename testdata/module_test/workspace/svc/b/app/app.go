@@ -0,0 +1,7 @@
+package app
+
+import "example.com/a/domain"
+
+func NewUser(name string) *domain.User {
+	return &domain.User{Name: name}
+}
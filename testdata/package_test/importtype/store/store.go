@@ -0,0 +1,12 @@
+package store
+
+import (
+	"fmt"
+
+	_ "github.com/lib/pq"
+	. "strings"
+)
+
+func Name(s string) string {
+	return fmt.Sprint(ToUpper(s))
+}
@@ -0,0 +1,7 @@
+package app
+
+import "sample/domain"
+
+func helper() {
+	domain.Do()
+}
@@ -0,0 +1,7 @@
+package app_test
+
+import "sample/app"
+
+func example() {
+	app.Run()
+}
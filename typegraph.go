@@ -0,0 +1,390 @@
+package prelviz
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+
+	"github.com/awalterschulze/gographviz"
+	"github.com/samber/lo"
+)
+
+const (
+	// LevelPackage draws the packages as nodes.
+	LevelPackage = "package"
+	// LevelType draws the exported types and functions as nodes.
+	LevelType = "type"
+)
+
+// TypeKindGrouping is the kind of the type level node which collapses the symbols of the packages in a grouping directory.
+const TypeKindGrouping = "grouping"
+
+// validateLevel returns an error for an unsupported level.
+func validateLevel(level string) error {
+	switch level {
+	case LevelPackage, LevelType, "":
+		return nil
+	default:
+		return fmt.Errorf("unsupported level: %s", level)
+	}
+}
+
+// collectSymbols returns the kind of each exported type and function declared in the file, and the identifiers which they use
+// from each package through field types, method signatures and bodies. Methods belong to the type of the receiver.
+// The import path is empty for the identifiers of the package itself, which are filtered by the declared symbols later.
+func collectSymbols(f *ast.File, importPathOf func(ident *ast.Ident) (string, bool)) (map[string]string, map[string]map[string]map[string]struct{}) {
+	symbolKindMap := make(map[string]string)
+	symbolUsageMap := make(map[string]map[string]map[string]struct{})
+	addUsages := func(symbol string, node ast.Node) {
+		if _, ok := symbolUsageMap[symbol]; !ok {
+			symbolUsageMap[symbol] = make(map[string]map[string]struct{})
+		}
+		walkSymbolUsages(node, importPathOf, func(importPath, name string) {
+			if _, ok := symbolUsageMap[symbol][importPath]; !ok {
+				symbolUsageMap[symbol][importPath] = make(map[string]struct{})
+			}
+			symbolUsageMap[symbol][importPath][name] = struct{}{}
+		})
+	}
+
+	for _, decl := range f.Decls {
+		switch x := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range x.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok || !typeSpec.Name.IsExported() {
+					continue
+				}
+				symbolKindMap[typeSpec.Name.Name] = IdentifierKindType
+				if _, ok = typeSpec.Type.(*ast.InterfaceType); ok {
+					symbolKindMap[typeSpec.Name.Name] = IdentifierKindInterface
+				}
+				if typeSpec.TypeParams != nil {
+					addUsages(typeSpec.Name.Name, typeSpec.TypeParams)
+				}
+				addUsages(typeSpec.Name.Name, typeSpec.Type)
+			}
+		case *ast.FuncDecl:
+			symbol, _, isMethod := strings.Cut(funcName(x), ".")
+			if !ast.IsExported(symbol) {
+				continue
+			}
+			if !isMethod {
+				symbolKindMap[symbol] = IdentifierKindFunc
+			}
+			addUsages(symbol, x.Type)
+			if x.Body != nil {
+				addUsages(symbol, x.Body)
+			}
+		}
+	}
+	return symbolKindMap, symbolUsageMap
+}
+
+// walkSymbolUsages calls add with the identifiers of the imported packages and the exported identifiers of the package itself in the node.
+// Selected fields and methods, field names and keys of composite literals are not package level identifiers, so they are skipped.
+func walkSymbolUsages(node ast.Node, importPathOf func(ident *ast.Ident) (string, bool), add func(importPath, name string)) {
+	var walk func(n ast.Node) bool
+	walk = func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.SelectorExpr:
+			if ident, ok := x.X.(*ast.Ident); ok {
+				if importPath, ok := importPathOf(ident); ok {
+					add(importPath, x.Sel.Name)
+					return false
+				}
+			}
+			ast.Inspect(x.X, walk)
+			return false
+		case *ast.KeyValueExpr:
+			if _, ok := x.Key.(*ast.Ident); !ok {
+				ast.Inspect(x.Key, walk)
+			}
+			ast.Inspect(x.Value, walk)
+			return false
+		case *ast.Field:
+			ast.Inspect(x.Type, walk)
+			return false
+		case *ast.Ident:
+			if x.IsExported() {
+				add("", x.Name)
+			}
+		}
+		return true
+	}
+	ast.Inspect(node, walk)
+}
+
+// TypeGraph is the dependency graph of the exported types and functions in the json format.
+// Module and Modules are the same as those of JSONGraph.
+type TypeGraph struct {
+	SchemaVersion int         `json:"schema_version"`
+	Module        string      `json:"module"`
+	Modules       []string    `json:"modules,omitempty"`
+	Nodes         []*TypeNode `json:"nodes"`
+	Edges         []*TypeEdge `json:"edges"`
+}
+
+// TypeNode is an exported type or function. ID is the import path of the package and the name of the symbol.
+// Package is the node name of the package at the package level.
+// The symbols of the packages in a grouping directory are collapsed into one node of the grouping kind,
+// whose ID is the node name of the grouping node, Name is the directory path and SymbolNum is the number of the symbols.
+type TypeNode struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Package   string `json:"package"`
+	SymbolNum int    `json:"symbol_num,omitempty"`
+}
+
+// TypeEdge is a dependency between symbols. The violation is of the relation between their packages.
+type TypeEdge struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Violation    bool   `json:"violation"`
+	ViolatedRule string `json:"violated_rule,omitempty"`
+}
+
+// label returns the name of the symbol with the kind, such as "User(type)", or the directory path with the number of the symbols
+// for a grouping node, such as "domain(symbols:12)".
+func (n *TypeNode) label() string {
+	if n.Kind == TypeKindGrouping {
+		return fmt.Sprintf("%s(symbols:%d)", n.Name, n.SymbolNum)
+	}
+	return fmt.Sprintf("%s(%s)", n.Name, n.Kind)
+}
+
+// typeGraph builds the graph of the exported types and functions of the packages which are not excluded.
+// Packages of tests are not drawn. The symbols of the packages in a grouping directory are collapsed into the grouping node,
+// so the dependencies between them are dropped, as well as those between the packages in a grouping node at the package level.
+func (m *Prelviz) typeGraph() *TypeGraph {
+	workspace := m.moduleWorkspace()
+	symbolNodeName := func(pkgDirPath, symbol string) (string, bool) {
+		info, ok := m.packageInfoMap[pkgDirPath]
+		if !ok || info.IsTest || m.isExcludePackageWithDirPath(pkgDirPath) {
+			return "", false
+		}
+		if _, ok = info.SymbolKindMap[symbol]; !ok {
+			return "", false
+		}
+		if m.isGroupingNode(pkgDirPath) {
+			return m.nodeName(pkgDirPath), true
+		}
+		return workspace.PackagePath(pkgDirPath) + "." + symbol, true
+	}
+
+	nodeMap := make(map[string]*TypeNode)
+	edgeMap := make(map[string]map[string]struct{})
+	for pkgDirPath, info := range m.packageInfoMap {
+		if info.IsTest || m.isExcludePackageWithDirPath(pkgDirPath) {
+			continue
+		}
+		pkgPath, pkgNodeName := workspace.PackagePath(pkgDirPath), m.nodeName(pkgDirPath)
+		if m.isGroupingNode(pkgDirPath) {
+			if len(info.SymbolKindMap) == 0 {
+				continue
+			}
+			if _, ok := nodeMap[pkgNodeName]; !ok {
+				nodeMap[pkgNodeName] = &TypeNode{ID: pkgNodeName, Name: m.groupingPackageDirectoryPath(pkgDirPath), Kind: TypeKindGrouping, Package: pkgNodeName}
+			}
+			nodeMap[pkgNodeName].SymbolNum += len(info.SymbolKindMap)
+		} else {
+			for symbol, kind := range info.SymbolKindMap {
+				nodeName := pkgPath + "." + symbol
+				nodeMap[nodeName] = &TypeNode{ID: nodeName, Name: symbol, Kind: kind, Package: pkgNodeName}
+			}
+		}
+		for symbol, importUsageMap := range info.SymbolUsageMap {
+			srcNodeName, ok := symbolNodeName(pkgDirPath, symbol)
+			if !ok {
+				continue
+			}
+			for importPath, usageMap := range importUsageMap {
+				dstDirPath := pkgDirPath
+				if importPath != "" {
+					if m.isExcludePackage(importPath) {
+						continue
+					}
+					if dstDirPath, ok = workspace.PackageDirectoryPath(importPath); !ok {
+						continue
+					}
+				}
+				for usage := range usageMap {
+					dstNodeName, ok := symbolNodeName(dstDirPath, usage)
+					if !ok || dstNodeName == srcNodeName {
+						continue
+					}
+					if _, ok = edgeMap[srcNodeName]; !ok {
+						edgeMap[srcNodeName] = make(map[string]struct{})
+					}
+					edgeMap[srcNodeName][dstNodeName] = struct{}{}
+				}
+			}
+		}
+	}
+
+	graph := &TypeGraph{
+		SchemaVersion: JSONSchemaVersion,
+		Module:        m.projectModuleName,
+		Nodes:         make([]*TypeNode, 0, len(nodeMap)),
+		Edges:         make([]*TypeEdge, 0),
+	}
+	if workspace.IsMultiModule() {
+		graph.Modules = workspace.ModulePaths()
+	}
+	for _, nodeName := range sortedKeys(nodeMap) {
+		graph.Nodes = append(graph.Nodes, nodeMap[nodeName])
+	}
+	for _, srcNodeName := range sortedKeys(edgeMap) {
+		for _, dstNodeName := range sortedKeys(edgeMap[srcNodeName]) {
+			rule := m.config.ViolatedRule(nodeMap[srcNodeName].Package, nodeMap[dstNodeName].Package)
+			graph.Edges = append(graph.Edges, &TypeEdge{
+				From:         srcNodeName,
+				To:           dstNodeName,
+				Violation:    rule != "",
+				ViolatedRule: rule,
+			})
+		}
+	}
+	return graph
+}
+
+// writeTypeGraph writes the graph of the exported types and functions in the format of dot, json or mermaid.
+func (m *Prelviz) writeTypeGraph() error {
+	graph := m.typeGraph()
+	switch m.format {
+	case FormatDot, "":
+		return m.writeTypeDot(graph)
+	case FormatJSON:
+		encoder := json.NewEncoder(m.output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(graph)
+	case FormatMermaid:
+		_, err := fmt.Fprint(m.output, typeMermaid(graph))
+		return err
+	default:
+		return fmt.Errorf("unsupported format of the type level: %s", m.format)
+	}
+}
+
+// typeFillColors are the node colors of each kind in the spectral11 color scheme.
+var typeFillColors = map[string]string{
+	IdentifierKindType:      "10",
+	IdentifierKindInterface: "9",
+	IdentifierKindFunc:      "8",
+	TypeKindGrouping:        "11",
+}
+
+// writeTypeDot writes the type graph in the dot format. The symbols of a package are in the cluster of the package.
+func (m *Prelviz) writeTypeDot(typeGraph *TypeGraph) error {
+	nodeDefaultAttrs := map[string]string{
+		"shape":       `"box"`,
+		"style":       `"solid,filled"`,
+		"fontcolor":   "6",
+		"fontsize":    "14",
+		"color":       "7",
+		"colorscheme": `"spectral11"`,
+	}
+
+	graphAst, _ := gographviz.ParseString(`digraph d {}`)
+	graph := gographviz.NewGraph()
+	if err := gographviz.Analyse(graphAst, graph); err != nil {
+		return err
+	}
+	graphAttrs, err := gographviz.NewAttrs(map[string]string{
+		"charset":   `"UTF-8"`,
+		"label":     `"type relation"`,
+		"labelloc":  `"t"`,
+		"labeljust": `"c"`,
+		"bgcolor":   `"#343434"`,
+		"fontsize":  "18",
+		"fontcolor": `"white"`,
+		"style":     `"filled"`,
+		"rankdir":   `"TB"`,
+		"margin":    "0.5",
+		"layout":    fmt.Sprintf(`"%s"`, m.dotLayout),
+	})
+	if err != nil {
+		return err
+	}
+	graph.Attrs.Extend(graphAttrs)
+
+	packageSubGraphNameMap := make(map[string]string)
+	for _, node := range typeGraph.Nodes {
+		subGraphName, ok := packageSubGraphNameMap[node.Package]
+		if !ok {
+			subGraphName = fmt.Sprintf("cluster_package_%d", len(packageSubGraphNameMap))
+			if err = graph.AddSubGraph(graph.Name, subGraphName, map[string]string{
+				"label":     fmt.Sprintf(`"%s"`, node.Package),
+				"style":     `"dashed"`,
+				"color":     `"white"`,
+				"fontcolor": `"white"`,
+			}); err != nil {
+				return err
+			}
+			packageSubGraphNameMap[node.Package] = subGraphName
+		}
+		if err = graph.AddNode(subGraphName, m.toDotLangFormat(node.ID), lo.Assign(nodeDefaultAttrs, map[string]string{
+			"fillcolor": typeFillColors[node.Kind],
+			"label":     fmt.Sprintf(`"%s"`, node.label()),
+		})); err != nil {
+			return err
+		}
+	}
+
+	for _, edge := range typeGraph.Edges {
+		color := `"white"`
+		if edge.Violation {
+			color = `"red"`
+		}
+		if err = graph.AddEdge(m.toDotLangFormat(edge.From), m.toDotLangFormat(edge.To), true, map[string]string{
+			"color": color,
+		}); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprint(m.output, graph.String())
+	return err
+}
+
+// typeMermaid returns the type graph as a mermaid flowchart. The packages are subgraphs.
+func typeMermaid(typeGraph *TypeGraph) string {
+	nodes := make([]*TypeNode, len(typeGraph.Nodes))
+	copy(nodes, typeGraph.Nodes)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Package < nodes[j].Package
+	})
+
+	var b strings.Builder
+	b.WriteString("flowchart TB\n")
+	b.WriteString("    classDef type fill:#3288bd,color:#ffffbf\n")
+	b.WriteString("    classDef interface fill:#66c2a5,color:#ffffbf\n")
+	b.WriteString("    classDef func fill:#abdda4,color:#5e4fa2\n")
+	b.WriteString("    classDef grouping fill:#5e4fa2,color:#ffffbf\n")
+	idMap := make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		if i == 0 || nodes[i-1].Package != node.Package {
+			if i > 0 {
+				b.WriteString("    end\n")
+			}
+			fmt.Fprintf(&b, "    subgraph p%d[\"%s\"]\n", i, node.Package)
+		}
+		idMap[node.ID] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", idMap[node.ID], node.label())
+		fmt.Fprintf(&b, "    class %s %s\n", idMap[node.ID], node.Kind)
+	}
+	if len(nodes) > 0 {
+		b.WriteString("    end\n")
+	}
+
+	for i, edge := range typeGraph.Edges {
+		fmt.Fprintf(&b, "    %s --> %s\n", idMap[edge.From], idMap[edge.To])
+		if edge.Violation {
+			fmt.Fprintf(&b, "    linkStyle %d stroke:red\n", i)
+		}
+	}
+	return b.String()
+}
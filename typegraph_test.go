@@ -0,0 +1,184 @@
+package prelviz
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func Test_collectSymbols(t *testing.T) {
+	src := `package usecase
+
+import "mod/domain"
+
+type UserUsecase struct {
+	Repository domain.Repository
+	Now        Clock
+}
+
+type Clock interface {
+	Now() domain.Time
+}
+
+type user struct{}
+
+func NewUserUsecase(r domain.Repository) *UserUsecase {
+	return &UserUsecase{Repository: r}
+}
+
+func (u *UserUsecase) Find(id string) (*domain.User, error) {
+	user, err := u.Repository.Find(id)
+	return domain.Validate(user), err
+}
+
+func helper() domain.User {
+	return domain.User{}
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "usecase.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	importPathOf := func(ident *ast.Ident) (string, bool) {
+		if ident.Name == "domain" {
+			return "mod/domain", true
+		}
+		return "", false
+	}
+	gotKindMap, gotUsageMap := collectSymbols(f, importPathOf)
+	wantKindMap := map[string]string{
+		"UserUsecase":    IdentifierKindType,
+		"Clock":          IdentifierKindInterface,
+		"NewUserUsecase": IdentifierKindFunc,
+	}
+	wantUsageMap := map[string]map[string]map[string]struct{}{
+		"UserUsecase": {
+			"mod/domain": {"Repository": {}, "User": {}, "Validate": {}},
+			"":           {"Clock": {}},
+		},
+		"Clock": {
+			"mod/domain": {"Time": {}},
+		},
+		"NewUserUsecase": {
+			"mod/domain": {"Repository": {}},
+			"":           {"UserUsecase": {}},
+		},
+	}
+	if !reflect.DeepEqual(gotKindMap, wantKindMap) {
+		t.Errorf("collectSymbols() kinds = %v, want %v", gotKindMap, wantKindMap)
+	}
+	if !reflect.DeepEqual(gotUsageMap, wantUsageMap) {
+		t.Errorf("collectSymbols() usages = %v, want %v", gotUsageMap, wantUsageMap)
+	}
+}
+
+func TestPrelviz_typeGraph(t *testing.T) {
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"usecase": {
+				Name:          "usecase",
+				DirectoryPath: "usecase",
+				SymbolKindMap: map[string]string{"UserUsecase": IdentifierKindType, "NewUserUsecase": IdentifierKindFunc},
+				SymbolUsageMap: map[string]map[string]map[string]struct{}{
+					"UserUsecase": {
+						"mod/domain/model": {"User": {}, "MaxAge": {}},
+						"mod/infra":        {"DB": {}},
+						"":                 {"UserUsecase": {}},
+					},
+					"NewUserUsecase": {
+						"":                   {"UserUsecase": {}},
+						"mod/domain/service": {"NewService": {}},
+					},
+				},
+			},
+			"domain/model": {
+				Name:          "model",
+				DirectoryPath: "domain/model",
+				SymbolKindMap: map[string]string{"User": IdentifierKindType},
+			},
+			"domain/service": {
+				Name:          "service",
+				DirectoryPath: "domain/service",
+				SymbolKindMap: map[string]string{"NewService": IdentifierKindFunc},
+				SymbolUsageMap: map[string]map[string]map[string]struct{}{
+					"NewService": {"mod/domain/model": {"User": {}}},
+				},
+			},
+			"infra": {
+				Name:          "infra",
+				DirectoryPath: "infra",
+				SymbolKindMap: map[string]string{"DB": IdentifierKindType},
+			},
+		},
+		config: &Config{
+			NgRelationMap: map[string]map[string]struct{}{
+				"mod/usecase": {"mod/domain": {}},
+			},
+			GroupingDirectoryPaths: []string{"domain"},
+			ExcludePackageMap:      map[string]struct{}{"mod/infra": {}},
+		},
+	}
+	want := &TypeGraph{
+		SchemaVersion: JSONSchemaVersion,
+		Module:        "mod",
+		Nodes: []*TypeNode{
+			{ID: "mod/domain", Name: "domain", Kind: TypeKindGrouping, Package: "mod/domain", SymbolNum: 2},
+			{ID: "mod/usecase.NewUserUsecase", Name: "NewUserUsecase", Kind: IdentifierKindFunc, Package: "mod/usecase"},
+			{ID: "mod/usecase.UserUsecase", Name: "UserUsecase", Kind: IdentifierKindType, Package: "mod/usecase"},
+		},
+		Edges: []*TypeEdge{
+			{From: "mod/usecase.NewUserUsecase", To: "mod/domain", Violation: true, ViolatedRule: RuleNgRelation},
+			{From: "mod/usecase.NewUserUsecase", To: "mod/usecase.UserUsecase"},
+			{From: "mod/usecase.UserUsecase", To: "mod/domain", Violation: true, ViolatedRule: RuleNgRelation},
+		},
+	}
+	if got := m.typeGraph(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Prelviz.typeGraph() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPrelviz_typeGraph_sameNameInGrouping(t *testing.T) {
+	m := &Prelviz{
+		projectModuleName: "mod",
+		packageInfoMap: map[string]*PackageInfo{
+			"usecase": {
+				Name:          "usecase",
+				DirectoryPath: "usecase",
+				SymbolKindMap: map[string]string{"Run": IdentifierKindFunc},
+				SymbolUsageMap: map[string]map[string]map[string]struct{}{
+					"Run": {"mod/domain/model": {"User": {}}},
+				},
+			},
+			"domain/model": {
+				Name:          "model",
+				DirectoryPath: "domain/model",
+				SymbolKindMap: map[string]string{"User": IdentifierKindType},
+			},
+			"domain/repository": {
+				Name:          "repository",
+				DirectoryPath: "domain/repository",
+				SymbolKindMap: map[string]string{"User": IdentifierKindInterface},
+			},
+		},
+		config: &Config{
+			GroupingDirectoryPaths: []string{"domain"},
+		},
+	}
+	want := &TypeGraph{
+		SchemaVersion: JSONSchemaVersion,
+		Module:        "mod",
+		Nodes: []*TypeNode{
+			{ID: "mod/domain", Name: "domain", Kind: TypeKindGrouping, Package: "mod/domain", SymbolNum: 2},
+			{ID: "mod/usecase.Run", Name: "Run", Kind: IdentifierKindFunc, Package: "mod/usecase"},
+		},
+		Edges: []*TypeEdge{
+			{From: "mod/usecase.Run", To: "mod/domain"},
+		},
+	}
+	if got := m.typeGraph(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Prelviz.typeGraph() = %+v, want %+v", got, want)
+	}
+}